@@ -0,0 +1,94 @@
+// Command envcheck validates the current environment against a config spec
+// and exits non-zero, listing every missing or invalid variable, if it does
+// not satisfy it. It is meant for CI jobs and container entrypoints.
+//
+// The spec is the JSON produced by envParser.Spec for the config struct of
+// the program being checked, e.g. written at build time:
+//
+//	data, _ := envParser.Spec(&Config{})
+//	os.WriteFile("config.spec.json", data, 0o644)
+//
+// Usage:
+//
+//	envcheck [-env-file path]... [-only-files] spec.json
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+
+	envParser "github.com/pedrobarbosak/go-env-validator"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Environ(), os.Stdout, os.Stderr))
+}
+
+// run executes envcheck with args against the environment environ and
+// returns the exit code: 0 if the environment is valid, 1 if it is not and 2
+// for usage errors.
+func run(args, environ []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("envcheck", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: envcheck [-env-file path]... [-only-files] spec.json")
+		fs.PrintDefaults()
+	}
+
+	var envFiles []string
+	fs.Func("env-file", "`path` of a .env file layered over the environment (repeatable)", func(path string) error {
+		envFiles = append(envFiles, path)
+		return nil
+	})
+	onlyFiles := fs.Bool("only-files", false, "ignore the process environment and check the .env files only")
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	spec, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(stderr, "envcheck:", err)
+		return 2
+	}
+
+	envs := map[string]string{}
+	if !*onlyFiles {
+		if envs, err = envParser.EnvironToMap(environ); err != nil {
+			fmt.Fprintln(stderr, "envcheck:", err)
+			return 2
+		}
+	}
+	if len(envFiles) > 0 {
+		fileEnvs, err := envParser.ReadEnvFiles(envFiles...)
+		if err != nil {
+			fmt.Fprintln(stderr, "envcheck:", err)
+			return 2
+		}
+		maps.Copy(envs, fileEnvs)
+	}
+
+	err = envParser.CheckSpec(spec, envs)
+	if err == nil {
+		fmt.Fprintln(stdout, "envcheck: ok")
+		return 0
+	}
+
+	var errs envParser.Errors
+	if !errors.As(err, &errs) {
+		fmt.Fprintln(stderr, "envcheck:", err)
+		return 2
+	}
+	for _, e := range errs {
+		fmt.Fprintln(stderr, e)
+	}
+	return 1
+}
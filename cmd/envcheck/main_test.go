@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	spec := filepath.Join(dir, "spec.json")
+	if err := os.WriteFile(spec, []byte(`[
+		{"key": "HOST", "type": "string", "required": true},
+		{"key": "PORT", "type": "int", "required": false, "default": "8080"},
+		{"key": "LOG_LEVEL", "type": "string", "required": false, "oneof": ["debug", "info"]}
+	]`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	envFile := filepath.Join(dir, ".env")
+	if err := os.WriteFile(envFile, []byte("HOST=db\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		environ    []string
+		wantCode   int
+		wantStderr []string
+	}{
+		{"valid", []string{spec}, []string{"HOST=localhost", "PORT=80"}, 0, nil},
+		{"invalid", []string{spec}, []string{"PORT=http", "LOG_LEVEL=trace"}, 1, []string{"HOST: required field not found", "PORT: ", "LOG_LEVEL: must be one of debug, info"}},
+		{"env file", []string{"-env-file", envFile, spec}, nil, 0, nil},
+		{"only files", []string{"-only-files", spec}, []string{"HOST=localhost"}, 1, []string{"HOST: required field not found"}},
+		{"no spec", nil, nil, 2, []string{"usage: envcheck"}},
+		{"missing spec", []string{filepath.Join(dir, "missing.json")}, nil, 2, []string{"envcheck:"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr strings.Builder
+			if code := run(tt.args, tt.environ, &stdout, &stderr); code != tt.wantCode {
+				t.Errorf("run() = %d, want %d\nstderr: %s", code, tt.wantCode, stderr.String())
+			}
+			for _, want := range tt.wantStderr {
+				if !strings.Contains(stderr.String(), want) {
+					t.Errorf("stderr = %q, want %q", stderr.String(), want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package envParser
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// defaultConsulAddress is the address of the local Consul agent.
+const defaultConsulAddress = "http://127.0.0.1:8500"
+
+// ConsulSource is a Source that reads every key under a prefix of the
+// Consul KV store. Keys are mapped to environment keys relative to the
+// prefix, with "/" joined by "_", so app/db/host under the prefix app/
+// becomes DB_HOST. Folders are skipped.
+type ConsulSource struct {
+	// Address of the Consul HTTP API. It defaults to CONSUL_HTTP_ADDR or
+	// http://127.0.0.1:8500; a missing scheme means http.
+	Address string
+	// Prefix is the KV path to read, e.g. "config/my-service/".
+	Prefix string
+	// Token is the ACL token. It defaults to CONSUL_HTTP_TOKEN.
+	Token string
+	// Datacenter selects the datacenter to query; empty means the agent's.
+	Datacenter string
+	// Timeout bounds the request. It defaults to 10 seconds.
+	Timeout time.Duration
+	// Client sends the request. It defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+// Load reads the keys under Prefix. A prefix without keys yields an empty
+// map.
+func (s *ConsulSource) Load(ctx context.Context) (map[string]string, error) {
+	address := s.Address
+	if address == "" {
+		address = os.Getenv("CONSUL_HTTP_ADDR")
+	}
+	if address == "" {
+		address = defaultConsulAddress
+	}
+	if !strings.Contains(address, "://") {
+		address = "http://" + address
+	}
+
+	token := s.Token
+	if token == "" {
+		token = os.Getenv("CONSUL_HTTP_TOKEN")
+	}
+
+	query := url.Values{"recurse": {"true"}}
+	if s.Datacenter != "" {
+		query.Set("dc", s.Datacenter)
+	}
+	endpoint := strings.TrimSuffix(address, "/") + "/v1/kv/" + strings.TrimPrefix(s.Prefix, "/") + "?" + query.Encode()
+
+	resp, body, err := httpGet(ctx, s.Client, s.Timeout, endpoint, func(req *http.Request) {
+		if token != "" {
+			req.Header.Set("X-Consul-Token", token)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	envs := make(map[string]string)
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return envs, nil
+	default:
+		return nil, fmt.Errorf("consul: read %q: unexpected status %s", s.Prefix, resp.Status)
+	}
+
+	var pairs []struct {
+		Key   string
+		Value *string
+	}
+	if err := json.Unmarshal(body, &pairs); err != nil {
+		return nil, fmt.Errorf("consul: read %q: invalid JSON: %w", s.Prefix, err)
+	}
+
+	for _, pair := range pairs {
+		name := strings.Trim(strings.TrimPrefix(pair.Key, strings.TrimPrefix(s.Prefix, "/")), "/")
+		if pair.Value == nil || name == "" || strings.HasSuffix(pair.Key, "/") {
+			continue
+		}
+
+		value, err := base64.StdEncoding.DecodeString(*pair.Value)
+		if err != nil {
+			return nil, fmt.Errorf("consul: decode %q: %w", pair.Key, err)
+		}
+		envs[EnvKey(name)] = string(value)
+	}
+
+	return envs, nil
+}
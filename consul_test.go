@@ -0,0 +1,66 @@
+package envParser
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConsulSource(t *testing.T) {
+	b64 := base64.StdEncoding.EncodeToString
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Consul-Token") != "secret" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.URL.Query().Get("recurse") != "true" {
+			t.Errorf("recurse = %q, want true", r.URL.Query().Get("recurse"))
+		}
+
+		switch {
+		case r.URL.Path == "/v1/kv/app/" && r.URL.Query().Get("dc") == "eu":
+			fmt.Fprintf(w, `[{"Key": "app/", "Value": null}, {"Key": "app/port", "Value": %q}, {"Key": "app/db/host", "Value": %q}, {"Key": "app/log-level", "Value": %q}]`,
+				b64([]byte("8080")), b64([]byte("db.local")), b64([]byte("debug")))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	t.Setenv("CONSUL_HTTP_TOKEN", "secret")
+
+	tests := []struct {
+		name    string
+		source  ConsulSource
+		want    map[string]string
+		wantErr string
+	}{
+		{"prefix", ConsulSource{Address: srv.URL, Prefix: "app/", Datacenter: "eu"}, map[string]string{"PORT": "8080", "DB_HOST": "db.local", "LOG_LEVEL": "debug"}, ""},
+		{"address without scheme", ConsulSource{Address: strings.TrimPrefix(srv.URL, "http://"), Prefix: "app/", Datacenter: "eu"}, map[string]string{"PORT": "8080", "DB_HOST": "db.local", "LOG_LEVEL": "debug"}, ""},
+		{"missing prefix", ConsulSource{Address: srv.URL, Prefix: "other/"}, map[string]string{}, ""},
+		{"forbidden", ConsulSource{Address: srv.URL, Prefix: "app/", Token: "wrong"}, nil, "unexpected status 403 Forbidden"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.source.Load(context.Background())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Load() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
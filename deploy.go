@@ -0,0 +1,108 @@
+package envParser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// KubernetesEnv returns the env section of a Kubernetes container spec for
+// v, with one entry per variable. Fields tagged secret are read from the
+// Secret named secretName through a secretKeyRef, optional unless the field
+// is required; the others get their default as value. Fields without a
+// default are left as comments to fill in, since an empty value would be
+// set as "" and fail to parse or satisfy required.
+// v must be a struct or a pointer to a struct.
+func KubernetesEnv(v interface{}, secretName string) (string, error) {
+	specs, err := fieldSpecs(v)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	sb.WriteString("env:\n")
+	for _, spec := range specs {
+		if !spec.Secret && spec.Default == "" {
+			note := "optional"
+			if spec.Required {
+				note = "required"
+			}
+			fmt.Fprintf(&sb, "  # - name: %s (%s, no default: set a value)\n", spec.Key, note)
+			continue
+		}
+
+		fmt.Fprintf(&sb, "  - name: %s\n", spec.Key)
+		if !spec.Secret {
+			fmt.Fprintf(&sb, "    value: %s\n", strconv.Quote(spec.Default))
+			continue
+		}
+
+		sb.WriteString("    valueFrom:\n")
+		sb.WriteString("      secretKeyRef:\n")
+		fmt.Fprintf(&sb, "        name: %s\n", strconv.Quote(secretName))
+		fmt.Fprintf(&sb, "        key: %s\n", spec.Key)
+		if !spec.Required {
+			sb.WriteString("        optional: true\n")
+		}
+	}
+
+	return sb.String(), nil
+}
+
+// ComposeEnv returns the environment section of a docker-compose service
+// for v, preceded by an env_file section listing envFiles if any are given.
+// Each variable is interpolated from the shell: required variables with
+// ${KEY:?...} so compose refuses to start without them, variables with a
+// default with ${KEY:-default} and others are passed through only if set.
+// Defaults containing }, which would end the interpolation, are an error.
+//
+// Compose gives environment precedence over env_file, so the keys set in
+// envFiles, which are read to find them, are left out of environment.
+// v must be a struct or a pointer to a struct.
+func ComposeEnv(v interface{}, envFiles ...string) (string, error) {
+	specs, err := fieldSpecs(v)
+	if err != nil {
+		return "", err
+	}
+
+	provided, err := ReadEnvFiles(envFiles...)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	if len(envFiles) > 0 {
+		sb.WriteString("env_file:\n")
+		for _, file := range envFiles {
+			fmt.Fprintf(&sb, "  - %s\n", strconv.Quote(file))
+		}
+	}
+
+	sb.WriteString("environment:\n")
+	for _, spec := range specs {
+		if _, ok := provided[spec.Key]; ok {
+			continue
+		}
+
+		var value string
+		switch {
+		case spec.Required && spec.Default == "":
+			value = fmt.Sprintf("${%s:?%s is required}", spec.Key, spec.Key)
+		case spec.Default != "":
+			// $ starts an interpolation in compose files, $$ is a literal $.
+			// A } would end it and has no escape.
+			if strings.Contains(spec.Default, "}") {
+				return "", fmt.Errorf("%s: default %q contains }, which compose interpolation cannot express", spec.Key, spec.Default)
+			}
+			value = fmt.Sprintf("${%s:-%s}", spec.Key, strings.ReplaceAll(spec.Default, "$", "$$"))
+		default:
+			// A key without a value is taken from the shell if set there
+			// and left unset otherwise, rather than set to "".
+			fmt.Fprintf(&sb, "  %s:\n", spec.Key)
+			continue
+		}
+		fmt.Fprintf(&sb, "  %s: %s\n", spec.Key, strconv.Quote(value))
+	}
+
+	return sb.String(), nil
+}
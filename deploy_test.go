@@ -0,0 +1,109 @@
+package envParser
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestKubernetesEnv(t *testing.T) {
+	type Config struct {
+		Host     string `env:"HOST,required"`
+		Port     int    `env:"PORT,default=8080"`
+		Workers  int    `env:"WORKERS"`
+		Password string `env:"DB_PASSWORD,required,secret"`
+		Token    string `env:"API_TOKEN,secret"`
+	}
+
+	got, err := KubernetesEnv(&Config{}, "app-secrets")
+	if err != nil {
+		t.Fatalf("KubernetesEnv() error = %v", err)
+	}
+
+	want := `env:
+  # - name: HOST (required, no default: set a value)
+  - name: PORT
+    value: "8080"
+  # - name: WORKERS (optional, no default: set a value)
+  - name: DB_PASSWORD
+    valueFrom:
+      secretKeyRef:
+        name: "app-secrets"
+        key: DB_PASSWORD
+  - name: API_TOKEN
+    valueFrom:
+      secretKeyRef:
+        name: "app-secrets"
+        key: API_TOKEN
+        optional: true
+`
+	if got != want {
+		t.Errorf("KubernetesEnv() =\n%s\nwant\n%s", got, want)
+	}
+
+	if _, err := KubernetesEnv(nil, "s"); err != ErrInvalidValue {
+		t.Errorf("KubernetesEnv(nil) error = %v, want ErrInvalidValue", err)
+	}
+}
+
+func TestComposeEnv(t *testing.T) {
+	type Config struct {
+		Host   string `env:"HOST,required"`
+		Port   int    `env:"PORT,default=8080"`
+		Prompt string `env:"PROMPT,default=$ "`
+		Token  string `env:"API_TOKEN"`
+	}
+
+	got, err := ComposeEnv(Config{})
+	if err != nil {
+		t.Fatalf("ComposeEnv() error = %v", err)
+	}
+
+	want := `environment:
+  HOST: "${HOST:?HOST is required}"
+  PORT: "${PORT:-8080}"
+  PROMPT: "${PROMPT:-$$ }"
+  API_TOKEN:
+`
+	if got != want {
+		t.Errorf("ComposeEnv() =\n%s\nwant\n%s", got, want)
+	}
+
+	dir := t.TempDir()
+	envFile := filepath.Join(dir, ".env")
+	localFile := filepath.Join(dir, ".env.local")
+	if err := os.WriteFile(envFile, []byte("HOST=db\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(localFile, []byte("PORT=9090\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err = ComposeEnv(Config{}, envFile, localFile)
+	if err != nil {
+		t.Fatalf("ComposeEnv() error = %v", err)
+	}
+
+	// Keys set in the env files must not be overridden by environment.
+	want = "env_file:\n  - " + strconv.Quote(envFile) + "\n  - " + strconv.Quote(localFile) + `
+environment:
+  PROMPT: "${PROMPT:-$$ }"
+  API_TOKEN:
+`
+	if got != want {
+		t.Errorf("ComposeEnv() =\n%s\nwant\n%s", got, want)
+	}
+
+	if _, err := ComposeEnv(Config{}, filepath.Join(dir, "missing.env")); err == nil {
+		t.Error("expected error for missing env file")
+	}
+
+	type Pattern struct {
+		Match string `env:"MATCH,default=a}b"`
+	}
+	if _, err := ComposeEnv(Pattern{}); err == nil || !strings.Contains(err.Error(), "MATCH") {
+		t.Errorf("ComposeEnv() error = %v, want error for } in MATCH default", err)
+	}
+}
@@ -0,0 +1,160 @@
+package envParser
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// ErrInvalidDotenvKey is returned when a DOTENV_KEY is malformed.
+var ErrInvalidDotenvKey = errors.New("invalid DOTENV_KEY")
+
+// UnmarshalFromEnvVault decrypts the .env.vault file at path with the key in
+// the DOTENV_KEY environment variable and unmarshals it into v, merged with
+// the current system environment variables like UnmarshalFromFile.
+// v must be a non-nil pointer to a struct.
+func UnmarshalFromEnvVault(path string, v interface{}) error {
+	envs, err := ReadEnvVault(path, os.Getenv("DOTENV_KEY"))
+	if err != nil {
+		return err
+	}
+
+	base, err := EnvironToMap(os.Environ())
+	if err != nil {
+		return err
+	}
+
+	for key, value := range envs {
+		if _, exists := base[key]; exists && Duplicates == FirstWins {
+			continue
+		}
+		base[key] = value
+	}
+
+	return Unmarshal(base, v)
+}
+
+// ReadEnvVault decrypts the .env.vault file at path, as produced by
+// dotenv-vault, and returns its variables. dotenvKey is a DOTENV_KEY such as
+//
+//	dotenv://:key_1234…@dotenv.org/vault/.env.vault?environment=production
+//
+// whose environment selects the DOTENV_VAULT_PRODUCTION entry of the file.
+// dotenvKey may list several comma-separated keys, e.g. during rotation;
+// the first that decrypts its environment is used.
+func ReadEnvVault(path, dotenvKey string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := parseEnvFile(path, string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	vault, err := EnvironToMap(entries)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := decryptEnvVault(vault, dotenvKey)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	// The decrypted content is parsed on its own: includes and expansion
+	// would let it read local files and the environment, and parse errors
+	// would point at lines of the vault file and quote secret content.
+	p := newEnvFileParser(noIncludes)
+	p.expand = false
+	envEntries, err := p.parse("", plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("%s: decrypted environment is not a valid .env file", path)
+	}
+
+	return EnvironToMap(envEntries)
+}
+
+// EnvVaultSource returns a Source reading the .env.vault file at path like
+// ReadEnvVault, with the key in DOTENV_KEY at load time.
+func EnvVaultSource(path string) Source {
+	return SourceFunc(func(context.Context) (map[string]string, error) {
+		return ReadEnvVault(path, os.Getenv("DOTENV_KEY"))
+	})
+}
+
+// decryptEnvVault decrypts the environment of vault selected by dotenvKey,
+// trying each comma-separated key in turn.
+func decryptEnvVault(vault map[string]string, dotenvKey string) (string, error) {
+	if strings.TrimSpace(dotenvKey) == "" {
+		return "", fmt.Errorf("%w: not set", ErrInvalidDotenvKey)
+	}
+
+	var err error
+	for _, k := range strings.Split(dotenvKey, ",") {
+		plaintext, decErr := decryptEnvVaultKey(vault, strings.TrimSpace(k))
+		if decErr == nil {
+			return plaintext, nil
+		}
+		err = errors.Join(err, decErr)
+	}
+
+	return "", err
+}
+
+func decryptEnvVaultKey(vault map[string]string, dotenvKey string) (string, error) {
+	u, err := url.Parse(dotenvKey)
+	if err != nil || u.Scheme != "dotenv" || u.User == nil {
+		return "", fmt.Errorf("%w: want dotenv://:key_...@dotenv.org/vault/.env.vault?environment=...", ErrInvalidDotenvKey)
+	}
+
+	password, _ := u.User.Password()
+	key, err := hex.DecodeString(strings.TrimPrefix(password, "key_"))
+	if err != nil || len(key) != 32 {
+		return "", fmt.Errorf("%w: key must be 64 hex characters", ErrInvalidDotenvKey)
+	}
+
+	environment := u.Query().Get("environment")
+	if environment == "" {
+		return "", fmt.Errorf("%w: missing environment", ErrInvalidDotenvKey)
+	}
+
+	name := "DOTENV_VAULT_" + strings.ToUpper(environment)
+	encoded, ok := vault[name]
+	if !ok {
+		return "", fmt.Errorf("cannot find environment %s in vault", name)
+	}
+
+	ciphertext, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("%s: invalid base64: %w", name, err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	if len(ciphertext) < gcm.NonceSize()+gcm.Overhead() {
+		return "", fmt.Errorf("%s: ciphertext too short", name)
+	}
+
+	nonce, ciphertext := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("%s: decryption failed, check DOTENV_KEY", name)
+	}
+
+	return string(plaintext), nil
+}
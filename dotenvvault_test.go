@@ -0,0 +1,115 @@
+package envParser
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// encryptEnvVault encrypts plaintext the way dotenv-vault does.
+func encryptEnvVault(t *testing.T, key []byte, plaintext string) string {
+	t.Helper()
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(plaintext), nil))
+}
+
+func TestReadEnvVault(t *testing.T) {
+	defer func() { ExpandVariables, StrictEnvFile = false, false }()
+
+	prodKey := []byte(strings.Repeat("p", 32))
+	devKey := []byte(strings.Repeat("d", 32))
+	dotenvKey := func(key []byte, env string) string {
+		return "dotenv://:key_" + hex.EncodeToString(key) + "@dotenv.org/vault/.env.vault?environment=" + env
+	}
+
+	dir := t.TempDir()
+	local := filepath.Join(dir, "local.env")
+	if err := os.WriteFile(local, []byte("LOCAL_SECRET=x\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, ".env.vault")
+	content := "# .env.vault (generated)\n" +
+		`DOTENV_VAULT_PRODUCTION="` + encryptEnvVault(t, prodKey, "HOST=prod.example.com\nPORT=443\n") + "\"\n" +
+		`DOTENV_VAULT_DEVELOPMENT="` + encryptEnvVault(t, devKey, "HOST=localhost\nURL=${HOST}\n") + "\"\n" +
+		`DOTENV_VAULT_CI="` + encryptEnvVault(t, devKey, "#include "+local+"\n") + "\"\n" +
+		`DOTENV_VAULT_STRICT="` + encryptEnvVault(t, devKey, "SECRET-VALUE s3cret\n") + "\"\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		key     string
+		want    map[string]string
+		wantErr string
+	}{
+		{"production", dotenvKey(prodKey, "production"), map[string]string{"HOST": "prod.example.com", "PORT": "443"}, ""},
+		{"development", dotenvKey(devKey, "development"), map[string]string{"HOST": "localhost", "URL": "${HOST}"}, ""},
+		{"include", dotenvKey(devKey, "ci"), nil, "decrypted environment is not a valid .env file"},
+		{"rotation", dotenvKey(devKey, "production") + "," + dotenvKey(prodKey, "production"), map[string]string{"HOST": "prod.example.com", "PORT": "443"}, ""},
+		{"wrong key", dotenvKey(devKey, "production"), nil, "DOTENV_VAULT_PRODUCTION: decryption failed"},
+		{"missing environment", dotenvKey(prodKey, "staging"), nil, "cannot find environment DOTENV_VAULT_STAGING"},
+		{"unset", "", nil, "invalid DOTENV_KEY: not set"},
+		{"malformed", "dotenv://:key_xyz@dotenv.org/vault/.env.vault?environment=production", nil, "key must be 64 hex characters"},
+	}
+
+	// Decrypted content is never expanded.
+	ExpandVariables = true
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadEnvVault(path, tt.key)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ReadEnvVault() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadEnvVault() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	StrictEnvFile = true
+	if _, err := ReadEnvVault(path, dotenvKey(devKey, "strict")); err == nil || strings.Contains(err.Error(), "s3cret") || strings.Contains(err.Error(), "SECRET") {
+		t.Errorf("ReadEnvVault() error = %v, want an error without the content", err)
+	}
+	StrictEnvFile = false
+
+	if _, err := ReadEnvVault(path, "not a key"); !errors.Is(err, ErrInvalidDotenvKey) {
+		t.Errorf("ReadEnvVault() error = %v, want ErrInvalidDotenvKey", err)
+	}
+
+	t.Setenv("DOTENV_KEY", dotenvKey(prodKey, "production"))
+	var cfg struct {
+		Host string `env:"HOST"`
+		Port int    `env:"PORT"`
+	}
+	if err := UnmarshalFromEnvVault(path, &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Host != "prod.example.com" || cfg.Port != 443 {
+		t.Errorf("UnmarshalFromEnvVault() = %+v", cfg)
+	}
+}
@@ -0,0 +1,81 @@
+// Package envazure loads secrets from Azure Key Vault into the environment
+// map, so Azure-hosted services can keep using env-tagged structs.
+//
+//	cred, err := azidentity.NewDefaultAzureCredential(nil)
+//	...
+//	client, err := azsecrets.NewClient("https://my-vault.vault.azure.net/", cred, nil)
+//	...
+//	r := envParser.NewResolver(envParser.EnvSource(), &envazure.Source{Client: client})
+package envazure
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
+	envParser "github.com/pedrobarbosak/go-env-validator"
+)
+
+// Client is the part of *azsecrets.Client used by Source.
+type Client interface {
+	NewListSecretPropertiesPager(options *azsecrets.ListSecretPropertiesOptions) *runtime.Pager[azsecrets.ListSecretPropertiesResponse]
+	GetSecret(ctx context.Context, name string, version string, options *azsecrets.GetSecretOptions) (azsecrets.GetSecretResponse, error)
+}
+
+// Source is an envParser.Source serving the latest version of Key Vault
+// secrets. Secret names map to keys with envParser.EnvKey, since Key Vault
+// names cannot contain underscores, so db-password becomes DB_PASSWORD.
+type Source struct {
+	// Client is usually an *azsecrets.Client.
+	Client Client
+	// Names lists the secrets to read. If empty, every enabled secret in
+	// the vault is read.
+	Names []string
+}
+
+// Load reads the secrets.
+func (s *Source) Load(ctx context.Context) (map[string]string, error) {
+	names := s.Names
+	if len(names) == 0 {
+		var err error
+		if names, err = s.list(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	envs := make(map[string]string, len(names))
+	for _, name := range names {
+		resp, err := s.Client.GetSecret(ctx, name, "", nil)
+		if err != nil {
+			return nil, fmt.Errorf("keyvault: get %s: %w", name, err)
+		}
+		if resp.Value == nil {
+			continue
+		}
+		envs[envParser.EnvKey(name)] = *resp.Value
+	}
+
+	return envs, nil
+}
+
+// list returns the names of the enabled secrets in the vault.
+func (s *Source) list(ctx context.Context) ([]string, error) {
+	var names []string
+	pager := s.Client.NewListSecretPropertiesPager(nil)
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("keyvault: list secrets: %w", err)
+		}
+
+		for _, props := range page.Value {
+			if props.ID == nil || (props.Attributes != nil && props.Attributes.Enabled != nil && !*props.Attributes.Enabled) {
+				continue
+			}
+			names = append(names, props.ID.Name())
+		}
+	}
+
+	return names, nil
+}
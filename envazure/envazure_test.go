@@ -0,0 +1,86 @@
+package envazure
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
+)
+
+// fakeClient serves secrets from a map, listing them over two pages.
+type fakeClient struct {
+	secrets  map[string]string
+	disabled []string
+	pages    [][]string
+}
+
+func (c *fakeClient) NewListSecretPropertiesPager(*azsecrets.ListSecretPropertiesOptions) *runtime.Pager[azsecrets.ListSecretPropertiesResponse] {
+	page := 0
+	return runtime.NewPager(runtime.PagingHandler[azsecrets.ListSecretPropertiesResponse]{
+		More: func(azsecrets.ListSecretPropertiesResponse) bool {
+			return page < len(c.pages)
+		},
+		Fetcher: func(context.Context, *azsecrets.ListSecretPropertiesResponse) (azsecrets.ListSecretPropertiesResponse, error) {
+			var resp azsecrets.ListSecretPropertiesResponse
+			for _, name := range c.pages[page] {
+				id := azsecrets.ID("https://vault.vault.azure.net/secrets/" + name)
+				enabled := true
+				for _, d := range c.disabled {
+					enabled = enabled && d != name
+				}
+				resp.Value = append(resp.Value, &azsecrets.SecretProperties{ID: &id, Attributes: &azsecrets.SecretAttributes{Enabled: &enabled}})
+			}
+			page++
+			return resp, nil
+		},
+	})
+}
+
+func (c *fakeClient) GetSecret(_ context.Context, name, _ string, _ *azsecrets.GetSecretOptions) (azsecrets.GetSecretResponse, error) {
+	value, ok := c.secrets[name]
+	if !ok {
+		return azsecrets.GetSecretResponse{}, errors.New("SecretNotFound")
+	}
+	return azsecrets.GetSecretResponse{Secret: azsecrets.Secret{Value: &value}}, nil
+}
+
+func TestSource(t *testing.T) {
+	client := &fakeClient{
+		secrets:  map[string]string{"db-password": "s3cret", "api-key": "abc", "old-key": "x"},
+		disabled: []string{"old-key"},
+		pages:    [][]string{{"db-password", "old-key"}, {"api-key"}},
+	}
+
+	tests := []struct {
+		name    string
+		names   []string
+		want    map[string]string
+		wantErr string
+	}{
+		{"all enabled", nil, map[string]string{"DB_PASSWORD": "s3cret", "API_KEY": "abc"}, ""},
+		{"names", []string{"api-key"}, map[string]string{"API_KEY": "abc"}, ""},
+		{"missing", []string{"missing"}, nil, "keyvault: get missing: SecretNotFound"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Source{Client: client, Names: tt.names}
+			got, err := s.Load(context.Background())
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("Load() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Load() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
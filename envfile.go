@@ -0,0 +1,466 @@
+package envParser
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	pathpkg "path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// StrictEnvFile makes .env parsing reject malformed lines, such as lines
+// without '=', keys with invalid characters or unterminated quotes, with a
+// *ParseError naming the file and line. Disabled by default, in which case
+// bare keys are accepted and malformed quotes are kept verbatim.
+var StrictEnvFile = false
+
+// RejectDuplicateKeys makes .env parsing fail with a *ParseError for every
+// key assigned more than once in the same file, instead of keeping the last
+// assignment. Disabled by default.
+var RejectDuplicateKeys = false
+
+// ExpandVariables enables expansion of $VAR and ${VAR} references in
+// unquoted and double-quoted .env file values. References resolve against
+// keys defined earlier in the file, then the system environment; unknown
+// references expand to "". Disabled by default.
+var ExpandVariables = false
+
+var (
+	errMissingEquals = errors.New("missing '=' in assignment")
+	errInvalidKey    = errors.New("invalid key")
+	errBadQuote      = errors.New("unterminated or malformed quoted value")
+	errDuplicateKey  = errors.New("duplicate key")
+	errIncludeCycle  = errors.New("include cycle")
+	errNoIncludes    = errors.New("include directives are not allowed here")
+)
+
+// parseEnvFile parses the content of the .env file at path into KEY=value
+// entries. path is only used in errors and to resolve relative includes,
+// which are read from the OS filesystem.
+func parseEnvFile(path, content string) ([]string, error) {
+	return newEnvFileParser(osIncludes).parse(path, content)
+}
+
+// includeReader loads the files referenced by include directives.
+type includeReader struct {
+	// resolve returns the path of name included from the file at from.
+	resolve  func(from, name string) string
+	readFile func(name string) ([]byte, error)
+}
+
+var osIncludes = includeReader{
+	resolve: func(from, name string) string {
+		if filepath.IsAbs(name) {
+			return filepath.Clean(name)
+		}
+		return filepath.Join(filepath.Dir(from), name)
+	},
+	readFile: os.ReadFile,
+}
+
+// noIncludes rejects include directives, for content that does not come
+// from the local filesystem, such as remote config.
+var noIncludes = includeReader{
+	resolve: func(_, name string) string {
+		return name
+	},
+	readFile: func(string) ([]byte, error) {
+		return nil, errNoIncludes
+	},
+}
+
+// fsIncludes returns an includeReader reading slash-separated paths from fsys.
+func fsIncludes(fsys fs.FS) includeReader {
+	return includeReader{
+		resolve: func(from, name string) string {
+			return pathpkg.Join(pathpkg.Dir(from), name)
+		},
+		readFile: func(name string) ([]byte, error) {
+			return fs.ReadFile(fsys, name)
+		},
+	}
+}
+
+// envFileParser parses a .env file and the files it includes. Settings are
+// captured once so included files are parsed consistently.
+type envFileParser struct {
+	includes includeReader
+	// profile selects the [section] applied over the common block. Entries of
+	// other sections are ignored.
+	profile          string
+	strict           bool
+	rejectDuplicates bool
+	expand           bool
+	// defined holds the values assigned so far, across included files.
+	defined map[string]string
+	// active holds the files being parsed, to detect include cycles.
+	active map[string]bool
+}
+
+func newEnvFileParser(includes includeReader) *envFileParser {
+	return &envFileParser{
+		includes:         includes,
+		strict:           StrictEnvFile,
+		rejectDuplicates: RejectDuplicateKeys,
+		expand:           ExpandVariables,
+		defined:          make(map[string]string),
+		active:           make(map[string]bool),
+	}
+}
+
+func (p *envFileParser) parse(path, content string) ([]string, error) {
+	lines := strings.Split(content, "\n")
+	result := make([]string, 0, len(lines))
+	definedAt := make(map[string]int)
+	strict := p.strict
+
+	// Entries of the selected profile section replace the common entries of
+	// the same key, so they override them regardless of their position in
+	// the file or of Duplicates.
+	var profileEntries []string
+	section := ""
+	add := func(entries ...string) {
+		if section == "" {
+			result = append(result, entries...)
+		} else {
+			profileEntries = append(profileEntries, entries...)
+		}
+	}
+
+	if path != "" {
+		p.active[path] = true
+		defer delete(p.active, path)
+	}
+
+	var err error
+	lineErr := func(line int, e error) {
+		err = errors.Join(err, &ParseError{Path: path, Line: line, Err: e})
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], "\r")
+		line = strings.TrimSpace(line)
+
+		if line == "" {
+			continue
+		}
+
+		lineNo := i + 1
+		if name, ok := sectionHeader(line); ok {
+			section = name
+			continue
+		}
+
+		// Lines of other profiles are still parsed, so multiline values and
+		// strict errors are handled, but are otherwise ignored.
+		skip := section != "" && section != p.profile
+
+		if name, ok := includeDirective(line); ok {
+			if skip {
+				continue
+			}
+			entries, incErr := p.include(path, name)
+			if incErr != nil {
+				var perr *ParseError
+				if !errors.As(incErr, &perr) {
+					incErr = &ParseError{Path: path, Line: lineNo, Err: incErr}
+				}
+				err = errors.Join(err, incErr)
+				continue
+			}
+			add(entries...)
+			continue
+		}
+
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// Files that double as shell scripts prefix assignments with export.
+		if rest, ok := strings.CutPrefix(line, "export"); ok && rest != "" && (rest[0] == ' ' || rest[0] == '\t') {
+			line = strings.TrimSpace(rest)
+		}
+
+		// A bare key is present with an empty value, e.g. a DEBUG flag.
+		key, raw, found := strings.Cut(line, "=")
+		if !found {
+			key = stripComment(key)
+		}
+		key = strings.TrimSpace(key)
+		value := strings.TrimSpace(raw)
+
+		if strict {
+			if !found {
+				lineErr(lineNo, errMissingEquals)
+				continue
+			}
+			if !isValidKey(key) {
+				lineErr(lineNo, fmt.Errorf("%w %q", errInvalidKey, key))
+				continue
+			}
+		}
+
+		expand := p.expand
+		quoted := false
+		switch {
+		case strings.HasPrefix(value, `"`):
+			if inner, consumed, ok := readDoubleQuoted(value[1:], lines[i+1:]); ok {
+				// Escapes and references are resolved together, so \$ stays
+				// a literal $.
+				var expandFn func(string) string
+				if expand {
+					expandFn = func(s string) string { return expandValue(s, p.defined) }
+				}
+				value = unescape(inner, expandFn)
+				expand = false
+				i += consumed
+				quoted = true
+			}
+		case strings.HasPrefix(value, "'"):
+			// Single-quoted values are taken literally.
+			if end := strings.IndexByte(value[1:], '\''); end >= 0 && isComment(value[end+2:]) {
+				value = value[1 : end+1]
+				expand = false
+				quoted = true
+			}
+		}
+
+		if !quoted {
+			if strict && (strings.HasPrefix(value, `"`) || strings.HasPrefix(value, "'")) {
+				lineErr(lineNo, errBadQuote)
+				continue
+			}
+			value = strings.TrimSpace(stripComment(raw))
+		}
+
+		if skip {
+			continue
+		}
+
+		if expand {
+			value = expandValue(value, p.defined)
+		}
+
+		// A profile section may override keys of the common block.
+		sectionKey := section + "\x00" + key
+		if first, ok := definedAt[sectionKey]; ok && p.rejectDuplicates {
+			lineErr(lineNo, fmt.Errorf("%w %q, first defined on line %d", errDuplicateKey, key, first))
+			continue
+		}
+
+		definedAt[sectionKey] = lineNo
+		p.defined[key] = value
+		add(key + "=" + value)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(profileEntries) == 0 {
+		return result, nil
+	}
+
+	overridden := make(map[string]bool, len(profileEntries))
+	for _, entry := range profileEntries {
+		key, _, _ := strings.Cut(entry, "=")
+		overridden[key] = true
+	}
+	result = slices.DeleteFunc(result, func(entry string) bool {
+		key, _, _ := strings.Cut(entry, "=")
+		return overridden[key]
+	})
+
+	return append(result, profileEntries...), nil
+}
+
+// include parses the file name included from the file at from.
+func (p *envFileParser) include(from, name string) ([]string, error) {
+	path := p.includes.resolve(from, name)
+	if p.active[path] {
+		return nil, fmt.Errorf("%w: %s", errIncludeCycle, path)
+	}
+
+	data, err := p.includes.readFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.parse(path, string(data))
+}
+
+// sectionHeader reports whether line is a profile section header such as
+// [production] and returns the section name.
+func sectionHeader(line string) (string, bool) {
+	if len(line) < 2 || line[0] != '[' || line[len(line)-1] != ']' {
+		return "", false
+	}
+
+	return strings.TrimSpace(line[1 : len(line)-1]), true
+}
+
+// includeDirective reports whether line is an include directive, either
+// "#include path" or "source path", and returns the included path. The path
+// must be a single, optionally quoted, token; for the comment form it must
+// also name a .env file, so ordinary comments such as "#include notes" are
+// not mistaken for directives.
+func includeDirective(line string) (string, bool) {
+	for _, directive := range []string{"#include", "source"} {
+		rest, ok := strings.CutPrefix(line, directive)
+		if !ok || rest == "" || (rest[0] != ' ' && rest[0] != '\t') {
+			continue
+		}
+
+		name := strings.TrimSpace(rest)
+		if len(name) >= 2 && (name[0] == '"' || name[0] == '\'') && name[len(name)-1] == name[0] {
+			name = name[1 : len(name)-1]
+		} else if strings.ContainsAny(name, " \t") {
+			continue
+		}
+
+		if name == "" || name[0] == '=' {
+			continue
+		}
+		if directive == "#include" && !strings.Contains(pathpkg.Base(filepath.ToSlash(name)), ".env") {
+			continue
+		}
+
+		return name, true
+	}
+
+	return "", false
+}
+
+// isValidKey reports whether key is non-empty and made of letters, digits,
+// '_', '.' and '-', not starting with a digit.
+func isValidKey(key string) bool {
+	if key == "" || (key[0] >= '0' && key[0] <= '9') {
+		return false
+	}
+
+	for _, c := range key {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '_', c == '.', c == '-':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// readDoubleQuoted returns the raw content of a double-quoted value whose
+// opening quote has been stripped from first. If first has no closing quote,
+// the value continues over the following lines, joined by newlines, and
+// consumed reports how many of them were used. ok is false if the quote is
+// never closed or is followed by anything but a comment on its line.
+func readDoubleQuoted(first string, next []string) (inner string, consumed int, ok bool) {
+	raw := first
+	for {
+		if end := closingQuote(raw); end >= 0 {
+			if !isComment(raw[end+1:]) {
+				return "", 0, false
+			}
+			return raw[:end], consumed, true
+		}
+
+		if consumed == len(next) {
+			return "", 0, false
+		}
+
+		raw += "\n" + strings.TrimRight(next[consumed], "\r")
+		consumed++
+	}
+}
+
+// closingQuote returns the index of the first unescaped double quote in s,
+// or -1 if there is none.
+func closingQuote(s string) int {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+
+	return -1
+}
+
+// isComment reports whether rest, the text following a closing quote, is
+// empty or an inline comment.
+func isComment(rest string) bool {
+	rest = strings.TrimSpace(rest)
+	return rest == "" || rest[0] == '#'
+}
+
+// stripComment removes an inline comment from an unquoted value. A comment
+// starts at a # preceded by whitespace, so URL fragments like a#b are kept.
+func stripComment(value string) string {
+	for i := 1; i < len(value); i++ {
+		if value[i] == '#' && (value[i-1] == ' ' || value[i-1] == '\t') {
+			return value[:i]
+		}
+	}
+
+	return value
+}
+
+// escapes maps the characters following a backslash in double-quoted values
+// to what they stand for.
+var escapes = map[byte]string{
+	'n':  "\n",
+	'r':  "\r",
+	't':  "\t",
+	'"':  `"`,
+	'\\': `\`,
+	'$':  "$",
+}
+
+// unescape resolves the escape sequences supported in double-quoted values.
+// If expand is non-nil, it is applied to the text between escapes.
+func unescape(value string, expand func(string) string) string {
+	var sb strings.Builder
+	start := 0
+	flush := func(end int) {
+		text := value[start:end]
+		if expand != nil {
+			text = expand(text)
+		}
+		sb.WriteString(text)
+	}
+
+	for i := 0; i < len(value)-1; i++ {
+		if value[i] != '\\' {
+			continue
+		}
+		r, ok := escapes[value[i+1]]
+		if !ok {
+			continue
+		}
+
+		flush(i)
+		sb.WriteString(r)
+		i++
+		start = i + 1
+	}
+	flush(len(value))
+
+	return sb.String()
+}
+
+// expandValue replaces $VAR and ${VAR} in value with keys from defined,
+// falling back to the system environment.
+func expandValue(value string, defined map[string]string) string {
+	return os.Expand(value, func(name string) string {
+		if v, ok := defined[name]; ok {
+			return v
+		}
+
+		return os.Getenv(name)
+	})
+}
@@ -0,0 +1,665 @@
+package envParser
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestParseEnvFileExpand(t *testing.T) {
+	defer func() { ExpandVariables = false }()
+	t.Setenv("EXPAND_SYSTEM", "sys")
+
+	content := "HOST=localhost\nPORT=8080\nURL=http://${HOST}:$PORT/path\nFROM_SYSTEM=${EXPAND_SYSTEM}\nUNKNOWN=a${EXPAND_MISSING}b\nLATER=${DEFINED_LATER}\nDEFINED_LATER=x\nESCAPED=\"\\$HOST is $HOST\""
+
+	ExpandVariables = false
+	got, _ := parseEnvFile("", content)
+	if got[2] != "URL=http://${HOST}:$PORT/path" {
+		t.Errorf("expansion disabled: got %q", got[2])
+	}
+
+	ExpandVariables = true
+	got, _ = parseEnvFile("", content)
+	want := []string{
+		"HOST=localhost",
+		"PORT=8080",
+		"URL=http://localhost:8080/path",
+		"FROM_SYSTEM=sys",
+		"UNKNOWN=ab",
+		"LATER=",
+		"DEFINED_LATER=x",
+		"ESCAPED=$HOST is localhost",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("parseEnvFile() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("line %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestUnmarshalFromFileExpand(t *testing.T) {
+	defer func() { ExpandVariables = false }()
+	ExpandVariables = true
+
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("EXP_HOST=db\nEXP_DSN=postgres://${EXP_HOST}/app\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	type Config struct {
+		DSN string `env:"EXP_DSN"`
+	}
+
+	var cfg Config
+	if err := UnmarshalFromFileOnly(path, &cfg); err != nil {
+		t.Fatalf("UnmarshalFromFileOnly() error = %v", err)
+	}
+	if cfg.DSN != "postgres://db/app" {
+		t.Errorf("DSN = %q", cfg.DSN)
+	}
+}
+
+func TestParseEnvFileQuotes(t *testing.T) {
+	content := strings.Join([]string{
+		`DOUBLE="value with spaces"`,
+		`SINGLE='literal \n value'`,
+		`ESCAPES="line1\nline2\ttab \"quoted\" back\\slash"`,
+		`EMPTY=""`,
+		`SPACED = "  padded  "  `,
+		`UNQUOTED=plain value`,
+		`MISMATCHED="open`,
+		`INNER=a"b"c`,
+	}, "\n")
+
+	want := []string{
+		"DOUBLE=value with spaces",
+		`SINGLE=literal \n value`,
+		"ESCAPES=line1\nline2\ttab \"quoted\" back\\slash",
+		"EMPTY=",
+		"SPACED=  padded  ",
+		"UNQUOTED=plain value",
+		`MISMATCHED="open`,
+		`INNER=a"b"c`,
+	}
+
+	got, _ := parseEnvFile("", content)
+	if len(got) != len(want) {
+		t.Fatalf("parseEnvFile() = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("line %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParseEnvFileQuotesExpand(t *testing.T) {
+	defer func() { ExpandVariables = false }()
+	ExpandVariables = true
+
+	got, _ := parseEnvFile("", "NAME=world\nDOUBLE=\"hello ${NAME}\"\nSINGLE='hello ${NAME}'")
+	if got[1] != "DOUBLE=hello world" {
+		t.Errorf("double-quoted = %q, want expansion", got[1])
+	}
+	if got[2] != "SINGLE=hello ${NAME}" {
+		t.Errorf("single-quoted = %q, want literal", got[2])
+	}
+}
+
+func TestParseEnvFileMultiline(t *testing.T) {
+	content := "BEFORE=1\n" +
+		"CERT=\"-----BEGIN CERT-----\r\n" +
+		"MIIB\\\"abc\n" +
+		"  indented\n" +
+		"-----END CERT-----\"\n" +
+		"JSON=\"{\n  \\\"a\\\": 1\n}\"\n" +
+		"AFTER=2\n" +
+		"UNTERMINATED=\"open\n" +
+		"LAST=3"
+
+	want := []string{
+		"BEFORE=1",
+		"CERT=-----BEGIN CERT-----\nMIIB\"abc\n  indented\n-----END CERT-----",
+		"JSON={\n  \"a\": 1\n}",
+		"AFTER=2",
+		`UNTERMINATED="open`,
+		"LAST=3",
+	}
+
+	got, _ := parseEnvFile("", content)
+	if len(got) != len(want) {
+		t.Fatalf("parseEnvFile() = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("entry %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestUnmarshalFromFileMultiline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	content := "ML_KEY=\"line1\nline2\"\nML_NAME=app\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	type Config struct {
+		Key  string `env:"ML_KEY"`
+		Name string `env:"ML_NAME"`
+	}
+
+	var cfg Config
+	if err := UnmarshalFromFileOnly(path, &cfg); err != nil {
+		t.Fatalf("UnmarshalFromFileOnly() error = %v", err)
+	}
+	if cfg.Key != "line1\nline2" || cfg.Name != "app" {
+		t.Errorf("Unmarshal() = %+v", cfg)
+	}
+}
+
+func TestParseEnvFileExport(t *testing.T) {
+	content := "export HOST=localhost\nexport\tPORT=8080\nexport  QUOTED=\"a b\"\nexport FLAG\nexporter=x\nexport=y"
+
+	want := []string{
+		"HOST=localhost",
+		"PORT=8080",
+		"QUOTED=a b",
+		"FLAG=",
+		"exporter=x",
+		"export=y",
+	}
+
+	got, _ := parseEnvFile("", content)
+	if len(got) != len(want) {
+		t.Fatalf("parseEnvFile() = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("entry %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParseEnvFileInlineComments(t *testing.T) {
+	content := strings.Join([]string{
+		`PLAIN=value # comment`,
+		`TAB=value	# comment`,
+		`FRAGMENT=http://host/page#section`,
+		`HASH=#notacomment`,
+		`EMPTY= # only a comment`,
+		`DOUBLE="value # kept" # comment`,
+		`SINGLE='value # kept'   # comment`,
+		`TRAILING="value" extra`,
+		`MULTI="line1 # kept`,
+		`line2" # comment`,
+		`FLAG # bare key`,
+	}, "\n")
+
+	want := []string{
+		"PLAIN=value",
+		"TAB=value",
+		"FRAGMENT=http://host/page#section",
+		"HASH=#notacomment",
+		"EMPTY=",
+		"DOUBLE=value # kept",
+		"SINGLE=value # kept",
+		`TRAILING="value" extra`,
+		"MULTI=line1 # kept\nline2",
+		"FLAG=",
+	}
+
+	got, _ := parseEnvFile("", content)
+	if len(got) != len(want) {
+		t.Fatalf("parseEnvFile() = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("entry %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParseEnvFileStrict(t *testing.T) {
+	defer func() { StrictEnvFile = false }()
+
+	content := strings.Join([]string{
+		"# comment",
+		"GOOD=value",
+		"BARE",
+		"BAD KEY=x",
+		"1NUM=x",
+		`OPEN="never closed`,
+		"DOTTED.KEY-NAME=ok",
+		`TRAILING='value' extra`,
+	}, "\n")
+
+	StrictEnvFile = false
+	if _, err := parseEnvFile("app.env", content); err != nil {
+		t.Fatalf("lenient parseEnvFile() error = %v", err)
+	}
+
+	StrictEnvFile = true
+	got, err := parseEnvFile("app.env", content)
+	if err == nil {
+		t.Fatalf("strict parseEnvFile() = %q, want error", got)
+	}
+
+	msg := err.Error()
+	for _, want := range []string{"app.env:3:", "app.env:4:", "app.env:5:", "app.env:6:", "app.env:8:"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("error %q does not mention %s", msg, want)
+		}
+	}
+	for _, unwanted := range []string{"app.env:2:", "app.env:7:"} {
+		if strings.Contains(msg, unwanted) {
+			t.Errorf("error %q should not mention valid line %s", msg, unwanted)
+		}
+	}
+
+	var perr *ParseError
+	if !errors.As(err, &perr) || perr.Path != "app.env" || perr.Line != 3 {
+		t.Errorf("errors.As(*ParseError) = %+v", perr)
+	}
+}
+
+func TestUnmarshalFromFileStrict(t *testing.T) {
+	defer func() { StrictEnvFile = false }()
+	StrictEnvFile = true
+
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("OK=1\nMALFORMED\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg struct {
+		OK int `env:"OK"`
+	}
+	err := UnmarshalFromFileOnly(path, &cfg)
+	if err == nil || !strings.Contains(err.Error(), path+":2:") {
+		t.Errorf("UnmarshalFromFileOnly() error = %v, want %s:2", err, path)
+	}
+}
+
+func TestParseEnvFileRejectDuplicateKeys(t *testing.T) {
+	defer func() { RejectDuplicateKeys = false }()
+
+	content := "HOST=a\nPORT=1\nexport HOST=b\nOTHER=x\nHOST=c\n"
+
+	RejectDuplicateKeys = false
+	got, err := parseEnvFile("dup.env", content)
+	if err != nil {
+		t.Fatalf("parseEnvFile() error = %v", err)
+	}
+	if len(got) != 5 || got[4] != "HOST=c" {
+		t.Errorf("parseEnvFile() = %q, want last assignment kept", got)
+	}
+
+	RejectDuplicateKeys = true
+	_, err = parseEnvFile("dup.env", content)
+	if err == nil {
+		t.Fatal("expected duplicate key error")
+	}
+
+	msg := err.Error()
+	for _, want := range []string{`dup.env:3: duplicate key "HOST", first defined on line 1`, `dup.env:5: duplicate key "HOST", first defined on line 1`} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("error %q does not contain %q", msg, want)
+		}
+	}
+	if strings.Contains(msg, "PORT") || strings.Contains(msg, "OTHER") {
+		t.Errorf("error %q mentions unique keys", msg)
+	}
+}
+
+func TestUnmarshalFromReader(t *testing.T) {
+	t.Setenv("READER_SYSTEM", "system")
+
+	type Config struct {
+		Host   string `env:"READER_HOST"`
+		Port   int    `env:"READER_PORT"`
+		System string `env:"READER_SYSTEM"`
+	}
+
+	var cfg Config
+	r := strings.NewReader("# from a reader\nREADER_HOST=\"localhost\"\nREADER_PORT=8080\n")
+	if err := UnmarshalFromReader(r, &cfg); err != nil {
+		t.Fatalf("UnmarshalFromReader() error = %v", err)
+	}
+
+	if cfg.Host != "localhost" || cfg.Port != 8080 {
+		t.Errorf("UnmarshalFromReader() = %+v", cfg)
+	}
+	if cfg.System != "" {
+		t.Errorf("System = %q, reader content should not be merged with system env", cfg.System)
+	}
+}
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) { return 0, errors.New("read failed") }
+
+func TestUnmarshalFromReaderError(t *testing.T) {
+	var cfg struct{}
+	if err := UnmarshalFromReader(errReader{}, &cfg); err == nil || err.Error() != "read failed" {
+		t.Errorf("UnmarshalFromReader() error = %v, want read failed", err)
+	}
+}
+
+func TestUnmarshalFromFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"config/defaults.env": {Data: []byte("FS_HOST=embedded\nFS_PORT=9000\n")},
+		"config/broken.env":   {Data: []byte("FS_PORT=not-a-number\n")},
+	}
+
+	type Config struct {
+		Host string `env:"FS_HOST"`
+		Port int    `env:"FS_PORT"`
+	}
+
+	var cfg Config
+	if err := UnmarshalFromFS(fsys, "config/defaults.env", &cfg); err != nil {
+		t.Fatalf("UnmarshalFromFS() error = %v", err)
+	}
+	if cfg.Host != "embedded" || cfg.Port != 9000 {
+		t.Errorf("UnmarshalFromFS() = %+v", cfg)
+	}
+
+	if err := UnmarshalFromFS(fsys, "config/missing.env", &cfg); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("UnmarshalFromFS() missing file error = %v, want fs.ErrNotExist", err)
+	}
+	if err := UnmarshalFromFS(fsys, "config/broken.env", &cfg); err == nil {
+		t.Error("expected error for invalid FS_PORT")
+	}
+}
+
+func TestUnmarshalFromFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	base := write(".env", "LAYER_HOST=localhost\nLAYER_PORT=8080\nLAYER_DEBUG=true\n")
+	local := write(".env.local", "LAYER_PORT=9090\n")
+	prod := write(".env.production", "LAYER_HOST=prod.example.com\n")
+
+	type Config struct {
+		Host  string `env:"LAYER_HOST"`
+		Port  int    `env:"LAYER_PORT"`
+		Debug bool   `env:"LAYER_DEBUG"`
+	}
+
+	var cfg Config
+	if err := UnmarshalFromFilesOnly([]string{base, local, prod}, &cfg); err != nil {
+		t.Fatalf("UnmarshalFromFilesOnly() error = %v", err)
+	}
+	want := Config{Host: "prod.example.com", Port: 9090, Debug: true}
+	if cfg != want {
+		t.Errorf("UnmarshalFromFilesOnly() = %+v, want %+v", cfg, want)
+	}
+
+	t.Setenv("LAYER_DEBUG", "false")
+	t.Setenv("LAYER_PORT", "1")
+
+	cfg = Config{}
+	if err := UnmarshalFromFiles([]string{base, local}, &cfg); err != nil {
+		t.Fatalf("UnmarshalFromFiles() error = %v", err)
+	}
+	want = Config{Host: "localhost", Port: 9090, Debug: true}
+	if cfg != want {
+		t.Errorf("UnmarshalFromFiles() = %+v, want %+v", cfg, want)
+	}
+}
+
+func TestUnmarshalFromFilesErrors(t *testing.T) {
+	defer func() { StrictEnvFile = false }()
+	StrictEnvFile = true
+
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.env")
+	second := filepath.Join(dir, "second.env")
+	if err := os.WriteFile(first, []byte("BROKEN\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(second, []byte("OK=1\nALSO BROKEN\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg struct {
+		OK int `env:"OK"`
+	}
+
+	err := UnmarshalFromFilesOnly([]string{first, second}, &cfg)
+	if err == nil || !strings.Contains(err.Error(), first+":1:") || !strings.Contains(err.Error(), second+":2:") {
+		t.Errorf("UnmarshalFromFilesOnly() error = %v, want errors from both files", err)
+	}
+
+	err = UnmarshalFromFilesOnly([]string{filepath.Join(dir, "missing.env")}, &cfg)
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("UnmarshalFromFilesOnly() missing file error = %v, want os.ErrNotExist", err)
+	}
+}
+
+func TestParseEnvFileInclude(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "shared"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"shared/base.env": "HOST=localhost\nPORT=8080\n#include db.env\n",
+		"shared/db.env":   "DB=postgres\n",
+		".env":            "#include shared/base.env\n#include notes\n#include the following for staging\nPORT=9090\nsource \"extra.env\"\n",
+		"extra.env":       "EXTRA=1\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	path := filepath.Join(dir, ".env")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := parseEnvFile(path, string(data))
+	if err != nil {
+		t.Fatalf("parseEnvFile() error = %v", err)
+	}
+
+	want := []string{"HOST=localhost", "PORT=8080", "DB=postgres", "PORT=9090", "EXTRA=1"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("parseEnvFile() = %q, want %q", got, want)
+	}
+}
+
+func TestIncludeDirective(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+		ok   bool
+	}{
+		{"#include shared/base.env", "shared/base.env", true},
+		{"#include .env.local", ".env.local", true},
+		{`#include "with space.env"`, "with space.env", true},
+		{"source secrets", "secrets", true},
+		{"source 'extra.env'", "extra.env", true},
+		{"#include notes", "", false},
+		{"#include the following for staging", "", false},
+		{"#include base.env for staging", "", false},
+		{"source these two", "", false},
+		{"#includes base.env", "", false},
+		{"source=value", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := includeDirective(tt.line)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("includeDirective(%q) = %q, %v, want %q, %v", tt.line, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParseEnvFileIncludeErrors(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.env")
+	b := filepath.Join(dir, "b.env")
+	if err := os.WriteFile(a, []byte("A=1\n#include b.env\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(b, []byte("B=1\nsource a.env\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := parseEnvFile(a, "A=1\n#include b.env\n")
+	if !errors.Is(err, errIncludeCycle) {
+		t.Fatalf("parseEnvFile() error = %v, want include cycle", err)
+	}
+	var perr *ParseError
+	if !errors.As(err, &perr) || perr.Path != b || perr.Line != 2 {
+		t.Errorf("errors.As(*ParseError) = %+v, want %s:2", perr, b)
+	}
+
+	_, err = parseEnvFile(a, "#include missing.env\n")
+	if !errors.Is(err, os.ErrNotExist) || !errors.As(err, &perr) || perr.Line != 1 {
+		t.Errorf("parseEnvFile() missing include error = %v", err)
+	}
+}
+
+func TestUnmarshalFromFSInclude(t *testing.T) {
+	fsys := fstest.MapFS{
+		"config/app.env":    {Data: []byte("#include common.env\nINC_PORT=9000\n")},
+		"config/common.env": {Data: []byte("INC_HOST=embedded\nINC_PORT=80\n")},
+	}
+
+	var cfg struct {
+		Host string `env:"INC_HOST"`
+		Port int    `env:"INC_PORT"`
+	}
+	if err := UnmarshalFromFS(fsys, "config/app.env", &cfg); err != nil {
+		t.Fatalf("UnmarshalFromFS() error = %v", err)
+	}
+	if cfg.Host != "embedded" || cfg.Port != 9000 {
+		t.Errorf("UnmarshalFromFS() = %+v", cfg)
+	}
+}
+
+func TestParseEnvFileProfiles(t *testing.T) {
+	content := `HOST=localhost
+PORT=8080
+
+[production]
+HOST=prod.example.com
+CERT="line1
+line2"
+
+[development]
+DEBUG=true
+`
+
+	tests := []struct {
+		profile string
+		want    []string
+	}{
+		{"", []string{"HOST=localhost", "PORT=8080"}},
+		{"production", []string{"PORT=8080", "HOST=prod.example.com", "CERT=line1\nline2"}},
+		{"development", []string{"HOST=localhost", "PORT=8080", "DEBUG=true"}},
+		{"staging", []string{"HOST=localhost", "PORT=8080"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.profile, func(t *testing.T) {
+			p := newEnvFileParser(osIncludes)
+			p.profile = tt.profile
+
+			got, err := p.parse("", content)
+			if err != nil {
+				t.Fatalf("parse() error = %v", err)
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("parse() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshalFromFileProfile(t *testing.T) {
+	defer func() { RejectDuplicateKeys = false }()
+	RejectDuplicateKeys = true
+
+	path := filepath.Join(t.TempDir(), ".env")
+	content := "[production]\nPROFILE_HOST=prod.example.com\n\n[]\nPROFILE_HOST=localhost\nPROFILE_PORT=8080\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	type Config struct {
+		Host string `env:"PROFILE_HOST"`
+		Port int    `env:"PROFILE_PORT"`
+	}
+
+	var cfg Config
+	if err := UnmarshalFromFileProfile(path, "production", &cfg); err != nil {
+		t.Fatalf("UnmarshalFromFileProfile() error = %v", err)
+	}
+	if cfg.Host != "prod.example.com" || cfg.Port != 8080 {
+		t.Errorf("UnmarshalFromFileProfile() = %+v", cfg)
+	}
+
+	cfg = Config{}
+	if err := UnmarshalFromFile(path, &cfg); err != nil {
+		t.Fatalf("UnmarshalFromFile() error = %v", err)
+	}
+	if cfg.Host != "localhost" {
+		t.Errorf("UnmarshalFromFile() Host = %q, want common value", cfg.Host)
+	}
+
+	// The profile overrides the common block whichever duplicate wins.
+	defer func() { Duplicates = LastWins }()
+	Duplicates = FirstWins
+
+	cfg = Config{}
+	if err := UnmarshalFromFileProfile(path, "production", &cfg); err != nil {
+		t.Fatalf("UnmarshalFromFileProfile() error = %v", err)
+	}
+	if cfg.Host != "prod.example.com" {
+		t.Errorf("UnmarshalFromFileProfile() with FirstWins Host = %q, want profile value", cfg.Host)
+	}
+}
+
+func TestReadEnvFiles(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, ".env")
+	local := filepath.Join(dir, ".env.local")
+	if err := os.WriteFile(base, []byte("HOST=localhost\nPORT=8080\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(local, []byte("PORT=9090\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ReadEnvFiles(base, local)
+	if err != nil {
+		t.Fatalf("ReadEnvFiles() error = %v", err)
+	}
+	if want := map[string]string{"HOST": "localhost", "PORT": "9090"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadEnvFiles() = %v, want %v", got, want)
+	}
+
+	if _, err := ReadEnvFiles(filepath.Join(dir, "missing")); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
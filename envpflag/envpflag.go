@@ -0,0 +1,104 @@
+// Package envpflag binds env-tagged config structs to a pflag.FlagSet, so
+// CLIs built on github.com/spf13/pflag or cobra can reuse the same struct
+// for flags and environment variables.
+//
+//	var cfg Config
+//	flags, err := envpflag.Bind(cmd.Flags(), &cfg)
+//	...
+//	RunE: func(cmd *cobra.Command, args []string) error {
+//		err := envParser.NewResolver(envParser.EnvSource(), flags).Unmarshal(cmd.Context(), &cfg)
+//		...
+//	}
+package envpflag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+
+	envParser "github.com/pedrobarbosak/go-env-validator"
+	"github.com/spf13/pflag"
+)
+
+// Bind registers a flag on fs for every tagged field of v, named like
+// envParser.BindFlags does (DB_HOST becomes --db-host), with the field's
+// description and default in the help text. Boolean fields accept --name
+// without a value.
+//
+// The returned Source serves the flags set on the command line under their
+// keys; place it after the environment in a Resolver so flags override env
+// values. Flags are never marked required: a required field may be set by
+// either a flag or the environment, and Unmarshal reports it if neither
+// does. Fields left unset fall back to their default as usual.
+// v must be a struct or a pointer to a struct.
+func Bind(fs *pflag.FlagSet, v interface{}) (envParser.Source, error) {
+	infos, err := envParser.Describe(v)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string)
+	for _, info := range infos {
+		name := envParser.FlagName(info.Key)
+		if fs.Lookup(name) != nil {
+			err = errors.Join(err, fmt.Errorf("flag --%s for %s already defined", name, info.Key))
+			continue
+		}
+
+		usage := "env " + info.Key
+		if info.Description != "" {
+			usage = info.Description + " (" + usage + ")"
+		}
+
+		flag := fs.VarPF(&value{key: info.Key, values: values, typ: typeName(info.Type)}, name, "", usage)
+		flag.DefValue = info.Default
+		if isBool(info.Type) {
+			flag.NoOptDefVal = "true"
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return envParser.SourceFunc(func(context.Context) (map[string]string, error) {
+		return maps.Clone(values), nil
+	}), nil
+}
+
+// value is the pflag.Value Bind registers. It records the value under the
+// field's key once the flag is set.
+type value struct {
+	key    string
+	values map[string]string
+	typ    string
+}
+
+func (v *value) String() string {
+	return v.values[v.key]
+}
+
+func (v *value) Set(s string) error {
+	v.values[v.key] = s
+	return nil
+}
+
+func (v *value) Type() string {
+	return v.typ
+}
+
+// typeName returns the type shown in help texts, e.g. "int" for *int.
+func typeName(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Bool {
+		return "bool"
+	}
+	return t.String()
+}
+
+func isBool(t reflect.Type) bool {
+	return typeName(t) == "bool"
+}
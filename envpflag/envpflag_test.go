@@ -0,0 +1,83 @@
+package envpflag
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	envParser "github.com/pedrobarbosak/go-env-validator"
+	"github.com/spf13/pflag"
+)
+
+func TestBind(t *testing.T) {
+	type DB struct {
+		Host string `env:"HOST,required,doc=Database host"`
+	}
+
+	type Config struct {
+		Port  int    `env:"PORT,default=8080"`
+		Debug bool   `env:"DEBUG"`
+		Level string `env:"LOG_LEVEL,default=info"`
+		DB    DB     `envPrefix:"DB_"`
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		env     map[string]string
+		want    Config
+		wantErr bool
+	}{
+		{"env only", nil, map[string]string{"PORT": "9090", "DB_HOST": "env-db"}, Config{Port: 9090, Level: "info", DB: DB{Host: "env-db"}}, false},
+		{"flags override", []string{"--port=80", "--debug", "--db-host", "flag-db"}, map[string]string{"PORT": "9090", "DB_HOST": "env-db"}, Config{Port: 80, Debug: true, Level: "info", DB: DB{Host: "flag-db"}}, false},
+		{"required by flag", []string{"--db-host=flag-db"}, nil, Config{Port: 8080, Level: "info", DB: DB{Host: "flag-db"}}, false},
+		{"required missing", nil, nil, Config{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+
+			var cfg Config
+			flags, err := Bind(fs, &cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			err = envParser.NewResolver(envParser.MapSource(tt.env), flags).Unmarshal(context.Background(), &cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && cfg != tt.want {
+				t.Errorf("Unmarshal() = %+v, want %+v", cfg, tt.want)
+			}
+		})
+	}
+}
+
+func TestBindUsage(t *testing.T) {
+	type Config struct {
+		Host  string `env:"DB_HOST,doc=Database host"`
+		Port  int    `env:"PORT,default=8080"`
+		Debug bool   `env:"DEBUG"`
+	}
+
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	if _, err := Bind(fs, Config{}); err != nil {
+		t.Fatal(err)
+	}
+
+	usage := fs.FlagUsages()
+	for _, want := range []string{"--db-host string", "Database host (env DB_HOST)", "--port int", "env PORT (default 8080)", "--debug "} {
+		if !strings.Contains(usage, want) {
+			t.Errorf("usage = %q, want %q", usage, want)
+		}
+	}
+
+	if _, err := Bind(fs, Config{}); err == nil || !strings.Contains(err.Error(), "flag --port for PORT already defined") {
+		t.Errorf("Bind() error = %v, want already defined", err)
+	}
+}
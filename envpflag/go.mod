@@ -0,0 +1,16 @@
+module github.com/pedrobarbosak/go-env-validator/envpflag
+
+go 1.24
+
+require (
+	github.com/pedrobarbosak/go-env-validator v0.0.0-00010101000000-000000000000
+	github.com/spf13/pflag v1.0.10
+)
+
+require (
+	github.com/BurntSushi/toml v1.6.0 // indirect
+	github.com/kr/text v0.2.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/pedrobarbosak/go-env-validator => ../
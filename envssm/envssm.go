@@ -0,0 +1,71 @@
+// Package envssm loads parameters from AWS Systems Manager Parameter Store
+// into the environment map, so config can live in SSM while the code keeps
+// reading env-tagged structs.
+//
+//	cfg, err := config.LoadDefaultConfig(ctx)
+//	...
+//	params := &envssm.Source{Client: ssm.NewFromConfig(cfg), Path: "/my-service/prod"}
+//	r := envParser.NewResolver(envParser.EnvSource(), params)
+package envssm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	envParser "github.com/pedrobarbosak/go-env-validator"
+)
+
+// Source is an envParser.Source serving the parameters under a path. The
+// last segment of each parameter name becomes its key, mapped with
+// envParser.EnvKey, so /my-service/prod/db-host becomes DB_HOST.
+// SecureString parameters are decrypted, and the items of StringList
+// parameters are joined with envParser.Separator.
+type Source struct {
+	// Client is usually an *ssm.Client.
+	Client ssm.GetParametersByPathAPIClient
+	// Path is the parameter hierarchy to read, e.g. /my-service/prod.
+	Path string
+	// Recursive also reads the parameters of nested hierarchies. Two
+	// parameters with the same last segment are then an error.
+	Recursive bool
+}
+
+// Load reads every parameter under Path, following pagination.
+func (s *Source) Load(ctx context.Context) (map[string]string, error) {
+	input := &ssm.GetParametersByPathInput{
+		Path:           aws.String(s.Path),
+		Recursive:      aws.Bool(s.Recursive),
+		WithDecryption: aws.Bool(true),
+	}
+
+	envs := make(map[string]string)
+	names := make(map[string]string)
+	pages := ssm.NewGetParametersByPathPaginator(s.Client, input)
+	for pages.HasMorePages() {
+		page, err := pages.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("ssm: read %s: %w", s.Path, err)
+		}
+
+		for _, p := range page.Parameters {
+			name := aws.ToString(p.Name)
+			key := envParser.EnvKey(name[strings.LastIndex(name, "/")+1:])
+			if other, ok := names[key]; ok {
+				return nil, fmt.Errorf("ssm: parameters %s and %s both map to %s", other, name, key)
+			}
+			names[key] = name
+
+			value := aws.ToString(p.Value)
+			if p.Type == types.ParameterTypeStringList {
+				value = strings.ReplaceAll(value, ",", envParser.Separator)
+			}
+			envs[key] = value
+		}
+	}
+
+	return envs, nil
+}
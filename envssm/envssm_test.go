@@ -0,0 +1,84 @@
+package envssm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// fakeClient serves pages of parameters, one per call.
+type fakeClient struct {
+	pages [][]types.Parameter
+	err   error
+	calls []*ssm.GetParametersByPathInput
+}
+
+func (c *fakeClient) GetParametersByPath(_ context.Context, in *ssm.GetParametersByPathInput, _ ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error) {
+	c.calls = append(c.calls, in)
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	i := 0
+	if in.NextToken != nil {
+		i = len(aws.ToString(in.NextToken))
+	}
+	out := &ssm.GetParametersByPathOutput{Parameters: c.pages[i]}
+	if i+1 < len(c.pages) {
+		out.NextToken = aws.String(strings.Repeat("x", i+1))
+	}
+	return out, nil
+}
+
+func param(name, value string, typ types.ParameterType) types.Parameter {
+	return types.Parameter{Name: aws.String(name), Value: aws.String(value), Type: typ}
+}
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		name      string
+		client    *fakeClient
+		recursive bool
+		want      map[string]string
+		wantErr   string
+	}{
+		{"pages", &fakeClient{pages: [][]types.Parameter{
+			{param("/app/prod/db-host", "db.local", types.ParameterTypeString), param("/app/prod/password", "s3cret", types.ParameterTypeSecureString)},
+			{param("/app/prod/hosts", "a,b", types.ParameterTypeStringList)},
+		}}, false, map[string]string{"DB_HOST": "db.local", "PASSWORD": "s3cret", "HOSTS": "a;b"}, ""},
+		{"collision", &fakeClient{pages: [][]types.Parameter{
+			{param("/app/prod/host", "a", types.ParameterTypeString), param("/app/prod/db/host", "b", types.ParameterTypeString)},
+		}}, true, nil, "ssm: parameters /app/prod/host and /app/prod/db/host both map to HOST"},
+		{"error", &fakeClient{err: errors.New("access denied")}, false, nil, "ssm: read /app/prod: access denied"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Source{Client: tt.client, Path: "/app/prod", Recursive: tt.recursive}
+			got, err := s.Load(context.Background())
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("Load() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Load() = %v, want %v", got, tt.want)
+			}
+
+			in := tt.client.calls[0]
+			if aws.ToString(in.Path) != "/app/prod" || !aws.ToBool(in.WithDecryption) || aws.ToBool(in.Recursive) != tt.recursive {
+				t.Errorf("input = %+v", in)
+			}
+		})
+	}
+}
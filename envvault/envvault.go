@@ -0,0 +1,264 @@
+// Package envvault reads secrets from the HashiCorp Vault KV version 2
+// secrets engine into the environment map before Unmarshal, using the Vault
+// HTTP API directly.
+//
+// A whole secret can be loaded as a Source, its keys upper-cased:
+//
+//	vault := &envvault.Client{} // VAULT_ADDR and VAULT_TOKEN
+//	r := envParser.NewResolver(envParser.EnvSource(), vault.Source("secret/my-service"))
+//
+// Individual fields can name their secret with the vault tag option, as
+// mount/path#key:
+//
+//	type Config struct {
+//		DBPassword string `env:"DB_PASSWORD,vault=secret/db#password"`
+//	}
+//
+//	r := envParser.NewResolver(envParser.EnvSource(), vault.Fields(&cfg))
+package envvault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	envParser "github.com/pedrobarbosak/go-env-validator"
+)
+
+const (
+	defaultAddress = "http://127.0.0.1:8200"
+	defaultTimeout = 10 * time.Second
+)
+
+// Client reads KV v2 secrets. The zero value uses VAULT_ADDR, VAULT_TOKEN
+// and VAULT_NAMESPACE. A Client is safe for concurrent use.
+type Client struct {
+	// Address of the Vault server. It defaults to VAULT_ADDR or
+	// http://127.0.0.1:8200.
+	Address string
+	// Token authenticates requests. It defaults to VAULT_TOKEN.
+	Token string
+	// RoleID and SecretID, if RoleID is set and Token is empty, log in with
+	// the AppRole auth method mounted at approle/.
+	RoleID   string
+	SecretID string
+	// Namespace is the Vault Enterprise namespace. It defaults to
+	// VAULT_NAMESPACE.
+	Namespace string
+	// Timeout bounds each request. It defaults to 10 seconds.
+	Timeout time.Duration
+	// HTTPClient sends the requests. It defaults to http.DefaultClient.
+	HTTPClient *http.Client
+
+	mu    sync.Mutex
+	token string
+}
+
+// Source returns a Source serving every key of the secret at path, given as
+// mount/path such as secret/my-service. Keys are mapped with
+// envParser.EnvKey, so db-password becomes DB_PASSWORD.
+func (c *Client) Source(path string) envParser.Source {
+	return envParser.SourceFunc(func(ctx context.Context) (map[string]string, error) {
+		data, err := c.Read(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+
+		envs := make(map[string]string, len(data))
+		for key, value := range data {
+			envs[envParser.EnvKey(key)] = value
+		}
+		return envs, nil
+	})
+}
+
+// Fields returns a Source serving the fields of v tagged with the vault
+// option, mount/path#key, under their environment keys. Each secret is read
+// once per load. v must be a struct or a pointer to a struct.
+func (c *Client) Fields(v interface{}) envParser.Source {
+	return envParser.SourceFunc(func(ctx context.Context) (map[string]string, error) {
+		infos, err := envParser.Describe(v)
+		if err != nil {
+			return nil, err
+		}
+
+		envs := make(map[string]string)
+		secrets := make(map[string]map[string]string)
+		for _, info := range infos {
+			ref, ok := info.Extra["vault"]
+			if !ok {
+				continue
+			}
+
+			path, key, ok := strings.Cut(ref, "#")
+			if !ok || key == "" {
+				return nil, fmt.Errorf("vault: %s: invalid reference %q, want mount/path#key", info.Key, ref)
+			}
+
+			data, ok := secrets[path]
+			if !ok {
+				if data, err = c.Read(ctx, path); err != nil {
+					return nil, err
+				}
+				secrets[path] = data
+			}
+
+			value, ok := data[key]
+			if !ok {
+				return nil, fmt.Errorf("vault: %s: key %q not found in %s", info.Key, key, path)
+			}
+			envs[info.Key] = value
+		}
+
+		return envs, nil
+	})
+}
+
+// Read returns the latest version of the secret at path, given as
+// mount/path such as secret/my-service. Values that are not strings are
+// returned as JSON.
+func (c *Client) Read(ctx context.Context, path string) (map[string]string, error) {
+	mount, name, ok := strings.Cut(strings.Trim(path, "/"), "/")
+	if !ok || name == "" {
+		return nil, fmt.Errorf("vault: invalid secret path %q, want mount/path", path)
+	}
+
+	token, err := c.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Data struct {
+			Data map[string]json.RawMessage `json:"data"`
+		} `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/v1/"+mount+"/data/"+name, token, nil, &resp); err != nil {
+		return nil, fmt.Errorf("vault: read %s: %w", path, err)
+	}
+
+	data := make(map[string]string, len(resp.Data.Data))
+	for key, raw := range resp.Data.Data {
+		var s string
+		if json.Unmarshal(raw, &s) == nil {
+			data[key] = s
+		} else {
+			data[key] = string(raw)
+		}
+	}
+
+	return data, nil
+}
+
+// authenticate returns the token to use, logging in with AppRole on first
+// use if configured.
+func (c *Client) authenticate(ctx context.Context) (string, error) {
+	if c.Token != "" {
+		return c.Token, nil
+	}
+	if c.RoleID == "" {
+		return os.Getenv("VAULT_TOKEN"), nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" {
+		return c.token, nil
+	}
+
+	var resp struct {
+		Auth struct {
+			ClientToken string `json:"client_token"`
+		} `json:"auth"`
+	}
+	body := map[string]string{"role_id": c.RoleID, "secret_id": c.SecretID}
+	if err := c.do(ctx, http.MethodPost, "/v1/auth/approle/login", "", body, &resp); err != nil {
+		return "", fmt.Errorf("vault: approle login: %w", err)
+	}
+	if resp.Auth.ClientToken == "" {
+		return "", fmt.Errorf("vault: approle login: no client token returned")
+	}
+
+	c.token = resp.Auth.ClientToken
+	return c.token, nil
+}
+
+// do sends a request to the Vault API and decodes the JSON response into
+// out.
+func (c *Client) do(ctx context.Context, method, path, token string, in, out interface{}) error {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.address()+path, body)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if ns := c.namespace(); ns != "" {
+		req.Header.Set("X-Vault-Namespace", ns)
+	}
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Errors []string `json:"errors"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&apiErr) == nil && len(apiErr.Errors) > 0 {
+			return fmt.Errorf("unexpected status %s: %s", resp.Status, strings.Join(apiErr.Errors, "; "))
+		}
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (c *Client) address() string {
+	address := c.Address
+	if address == "" {
+		address = os.Getenv("VAULT_ADDR")
+	}
+	if address == "" {
+		address = defaultAddress
+	}
+	return strings.TrimSuffix(address, "/")
+}
+
+func (c *Client) namespace() string {
+	if c.Namespace != "" {
+		return c.Namespace
+	}
+	return os.Getenv("VAULT_NAMESPACE")
+}
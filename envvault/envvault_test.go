@@ -0,0 +1,123 @@
+package envvault
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	envParser "github.com/pedrobarbosak/go-env-validator"
+)
+
+func newServer(t *testing.T) (*httptest.Server, *int) {
+	t.Helper()
+
+	reads := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/auth/approle/login" {
+			var body map[string]string
+			json.NewDecoder(r.Body).Decode(&body)
+			if body["role_id"] != "role" || body["secret_id"] != "id" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"errors": ["invalid role or secret ID"]}`))
+				return
+			}
+			w.Write([]byte(`{"auth": {"client_token": "approle-token"}}`))
+			return
+		}
+
+		if token := r.Header.Get("X-Vault-Token"); token != "root" && token != "approle-token" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors": ["permission denied"]}`))
+			return
+		}
+
+		reads++
+		switch r.URL.Path {
+		case "/v1/secret/data/app":
+			w.Write([]byte(`{"data": {"data": {"db-password": "s3cret", "port": 5432}, "metadata": {"version": 2}}}`))
+		case "/v1/secret/data/api":
+			w.Write([]byte(`{"data": {"data": {"key": "abc"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors": []}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, &reads
+}
+
+func TestSource(t *testing.T) {
+	srv, _ := newServer(t)
+
+	tests := []struct {
+		name    string
+		client  *Client
+		path    string
+		want    map[string]string
+		wantErr string
+	}{
+		{"token", &Client{Address: srv.URL, Token: "root"}, "secret/app", map[string]string{"DB_PASSWORD": "s3cret", "PORT": "5432"}, ""},
+		{"approle", &Client{Address: srv.URL, RoleID: "role", SecretID: "id"}, "secret/app", map[string]string{"DB_PASSWORD": "s3cret", "PORT": "5432"}, ""},
+		{"approle rejected", &Client{Address: srv.URL, RoleID: "role", SecretID: "wrong"}, "secret/app", nil, "approle login: unexpected status 400 Bad Request: invalid role or secret ID"},
+		{"forbidden", &Client{Address: srv.URL, Token: "other"}, "secret/app", nil, "permission denied"},
+		{"not found", &Client{Address: srv.URL, Token: "root"}, "secret/missing", nil, "read secret/missing: unexpected status 404"},
+		{"invalid path", &Client{Address: srv.URL, Token: "root"}, "secret", nil, "invalid secret path"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.client.Source(tt.path).Load(context.Background())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Load() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFields(t *testing.T) {
+	srv, reads := newServer(t)
+
+	type Config struct {
+		Host     string `env:"DB_HOST"`
+		Password string `env:"DB_PASSWORD,vault=secret/app#db-password"`
+		Port     int    `env:"DB_PORT,vault=secret/app#port"`
+		APIKey   string `env:"API_KEY,required,vault=secret/api#key"`
+	}
+
+	client := &Client{Address: srv.URL, Token: "root"}
+
+	var cfg Config
+	r := envParser.NewResolver(envParser.MapSource(map[string]string{"DB_HOST": "db", "DB_PASSWORD": "env"}), client.Fields(&cfg))
+	if err := r.Unmarshal(context.Background(), &cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	want := Config{Host: "db", Password: "s3cret", Port: 5432, APIKey: "abc"}
+	if cfg != want {
+		t.Errorf("Unmarshal() = %+v, want %+v", cfg, want)
+	}
+	if *reads != 2 {
+		t.Errorf("secret reads = %d, want 2", *reads)
+	}
+
+	type Invalid struct {
+		Key string `env:"KEY,vault=secret/api#missing"`
+	}
+	if _, err := client.Fields(&Invalid{}).Load(context.Background()); err == nil || !strings.Contains(err.Error(), `KEY: key "missing" not found in secret/api`) {
+		t.Errorf("Load() error = %v, want key not found", err)
+	}
+}
@@ -2,6 +2,8 @@ package envParser
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 )
 
 var (
@@ -13,4 +15,108 @@ var (
 
 	// ErrUnsupportedType returned when a field with tag is unsupported.
 	ErrUnsupportedType = errors.New("field is an unsupported type")
+
+	// ErrInvalidTagOption returned in strict mode when a tag option does not apply to the field type.
+	ErrInvalidTagOption = errors.New("tag option is not valid for field type")
+
+	// ErrRequired returned, wrapped in a FieldError, when a required key is not set.
+	ErrRequired = errors.New("required field not found")
+
+	// ErrEmpty returned, wrapped in a FieldError, when a notEmpty key is set to an empty value.
+	ErrEmpty = errors.New("must not be empty")
+
+	// ErrUnknownKey returned with DisallowUnknownKeys for a key no field consumed.
+	ErrUnknownKey = errors.New("unknown key")
 )
+
+// ParseError describes a malformed line in a .env file.
+type ParseError struct {
+	Path string
+	Line int
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+	}
+
+	return fmt.Sprintf("%s:%d: %v", e.Path, e.Line, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// FieldError describes a failure to decode a single struct field.
+type FieldError struct {
+	// Struct is the name of the struct type declaring the field.
+	Struct string
+	// Field is the dotted path of the field from the decoded struct, e.g. DB.Port.
+	Field string
+	// Key is the environment key the field was read from.
+	Key string
+	// Value is the raw value that failed, empty if the key was not set.
+	Value string
+	// Err is the underlying cause.
+	Err error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Key, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// Errors is the aggregate returned when decoding fails. It unwraps to the
+// individual errors, most of which are *FieldError.
+type Errors []error
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+
+	return strings.Join(msgs, "\n")
+}
+
+func (e Errors) Unwrap() []error {
+	return e
+}
+
+// FieldErrors returns every FieldError in the tree of err, e.g. to map
+// failures back to the environment keys that caused them.
+func FieldErrors(err error) []*FieldError {
+	var fields []*FieldError
+	for _, e := range flattenErrors(err) {
+		var fe *FieldError
+		if errors.As(e, &fe) {
+			fields = append(fields, fe)
+		}
+	}
+
+	return fields
+}
+
+// flattenErrors expands err, including errors.Join results, into the list
+// of errors it aggregates.
+func flattenErrors(err error) []error {
+	if err == nil {
+		return nil
+	}
+
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return []error{err}
+	}
+
+	var errs []error
+	for _, e := range joined.Unwrap() {
+		errs = append(errs, flattenErrors(e)...)
+	}
+
+	return errs
+}
@@ -0,0 +1,87 @@
+package envParser
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"reflect"
+	"strings"
+)
+
+// BindFlags registers a command-line flag on fs for every tagged field of v,
+// named after its key in lower kebab case (DB_HOST becomes -db-host), with
+// the field's description and default in the help text. Boolean fields
+// accept -name without a value.
+//
+// The returned Source serves the flags set on the command line under their
+// keys; place it after the environment in a Resolver so flags override env
+// values:
+//
+//	flags, err := envParser.BindFlags(flag.CommandLine, &cfg)
+//	flag.Parse()
+//	err = envParser.NewResolver(envParser.EnvSource(), flags).Unmarshal(ctx, &cfg)
+//
+// Values are parsed by Unmarshal, not by fs. v must be a struct or a pointer
+// to a struct.
+func BindFlags(fs *flag.FlagSet, v interface{}) (Source, error) {
+	infos, err := Describe(v)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string)
+	for _, info := range infos {
+		name := FlagName(info.Key)
+		if fs.Lookup(name) != nil {
+			err = errors.Join(err, fmt.Errorf("flag -%s for %s already defined", name, info.Key))
+			continue
+		}
+
+		usage := "env " + info.Key
+		if info.Description != "" {
+			usage = info.Description + " (" + usage + ")"
+		}
+
+		fs.Var(&flagValue{key: info.Key, values: values, isBool: derefType(info.Type).Kind() == reflect.Bool}, name, usage)
+		fs.Lookup(name).DefValue = info.Default
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return SourceFunc(func(context.Context) (map[string]string, error) {
+		return maps.Clone(values), nil
+	}), nil
+}
+
+// FlagName returns the flag name BindFlags uses for key, e.g. "db-host" for
+// DB_HOST.
+func FlagName(key string) string {
+	return strings.ReplaceAll(strings.ToLower(key), "_", "-")
+}
+
+// flagValue is the flag.Value BindFlags registers. It records the value
+// under the field's key once the flag is set.
+type flagValue struct {
+	key    string
+	values map[string]string
+	isBool bool
+}
+
+func (f *flagValue) String() string {
+	if f == nil || f.values == nil {
+		return ""
+	}
+	return f.values[f.key]
+}
+
+func (f *flagValue) Set(s string) error {
+	f.values[f.key] = s
+	return nil
+}
+
+func (f *flagValue) IsBoolFlag() bool {
+	return f.isBool
+}
@@ -0,0 +1,78 @@
+package envParser
+
+import (
+	"context"
+	"flag"
+	"strings"
+	"testing"
+)
+
+func TestBindFlags(t *testing.T) {
+	type DB struct {
+		Host string `env:"HOST,doc=Database host"`
+	}
+
+	type Config struct {
+		Port  int    `env:"PORT,default=8080"`
+		Debug bool   `env:"DEBUG"`
+		Level string `env:"LOG_LEVEL,default=info"`
+		DB    DB     `envPrefix:"DB_"`
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want Config
+	}{
+		{"no flags", nil, Config{Port: 9090, Level: "warn", DB: DB{Host: "env-db"}}},
+		{"override", []string{"-port", "80", "-debug", "-db-host=flag-db"}, Config{Port: 80, Debug: true, Level: "warn", DB: DB{Host: "flag-db"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+
+			var cfg Config
+			flags, err := BindFlags(fs, &cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			env := MapSource(map[string]string{"PORT": "9090", "LOG_LEVEL": "warn", "DB_HOST": "env-db"})
+			if err := NewResolver(env, flags).Unmarshal(context.Background(), &cfg); err != nil {
+				t.Fatal(err)
+			}
+			if cfg != tt.want {
+				t.Errorf("Unmarshal() = %+v, want %+v", cfg, tt.want)
+			}
+		})
+	}
+}
+
+func TestBindFlagsUsage(t *testing.T) {
+	type Config struct {
+		Host string `env:"DB_HOST,doc=Database host"`
+		Port int    `env:"PORT,default=8080"`
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	if _, err := BindFlags(fs, Config{}); err != nil {
+		t.Fatal(err)
+	}
+
+	var b strings.Builder
+	fs.SetOutput(&b)
+	fs.PrintDefaults()
+	for _, want := range []string{"-db-host", "Database host (env DB_HOST)", "-port", "env PORT (default 8080)"} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("usage = %q, want %q", b.String(), want)
+		}
+	}
+
+	if _, err := BindFlags(fs, Config{}); err == nil || !strings.Contains(err.Error(), "flag -port for PORT already defined") {
+		t.Errorf("BindFlags() error = %v, want already defined", err)
+	}
+}
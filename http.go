@@ -0,0 +1,123 @@
+package envParser
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+)
+
+// defaultHTTPTimeout bounds requests of an HTTPSource without a Timeout.
+const defaultHTTPTimeout = 10 * time.Second
+
+// HTTPSource is a Source that fetches configuration from an HTTP(S)
+// endpoint, such as a centralized config service.
+//
+// Responses with a JSON content type must hold an object, which is flattened
+// to keys like a TOML document ({"db": {"host": "x"}} becomes DB_HOST);
+// any other response is parsed as a .env file of KEY=value lines.
+type HTTPSource struct {
+	// URL is the endpoint to GET.
+	URL string
+	// Header is added to the request, e.g. an Authorization header with a
+	// bearer token.
+	Header http.Header
+	// Username and Password, if Username is set, authenticate the request
+	// with HTTP basic auth.
+	Username string
+	Password string
+	// Timeout bounds the whole request. It defaults to 10 seconds.
+	Timeout time.Duration
+	// Client sends the request. It defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+// Load fetches and parses the configuration. Responses other than 200 OK
+// are errors.
+func (s *HTTPSource) Load(ctx context.Context) (map[string]string, error) {
+	resp, body, err := httpGet(ctx, s.Client, s.Timeout, s.URL, func(req *http.Request) {
+		for key, values := range s.Header {
+			req.Header[key] = append(req.Header[key], values...)
+		}
+		if s.Username != "" {
+			req.SetBasicAuth(s.Username, s.Password)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: unexpected status %s", s.URL, resp.Status)
+	}
+
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType == "application/json" {
+		return parseJSONObject(s.URL, body)
+	}
+
+	// Remote content must not make the parser read local files.
+	entries, err := newEnvFileParser(noIncludes).parse(s.URL, string(body))
+	if err != nil {
+		return nil, err
+	}
+
+	return EnvironToMap(entries)
+}
+
+// httpGet sends a GET request for url, customized by prepare, with client
+// (http.DefaultClient if nil) and returns the response and its body. The
+// whole request is bounded by timeout, or defaultHTTPTimeout if it is not
+// positive.
+func httpGet(ctx context.Context, client *http.Client, timeout time.Duration, url string, prepare func(*http.Request)) (*http.Response, []byte, error) {
+	if timeout <= 0 {
+		timeout = defaultHTTPTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if prepare != nil {
+		prepare(req)
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return resp, body, nil
+}
+
+// parseJSONObject flattens the JSON object in data, read from name, to
+// environment keys.
+func parseJSONObject(name string, data []byte) (map[string]string, error) {
+	var doc map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("%s: invalid JSON: %w", name, err)
+	}
+
+	envs := make(map[string]string)
+	for key, value := range doc {
+		flattenValue(envs, EnvKey(key), value)
+	}
+
+	return envs, nil
+}
@@ -0,0 +1,74 @@
+package envParser
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTTPSource(t *testing.T) {
+	localFile := filepath.Join(t.TempDir(), "local.env")
+	if err := os.WriteFile(localFile, []byte("LOCAL_SECRET=x\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); r.Header.Get("Authorization") != "Bearer token" && (!ok || user != "u" || pass != "p") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/env":
+			w.Write([]byte("HOST=localhost\nPORT=8080\n"))
+		case "/json":
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.Write([]byte(`{"db": {"host": "db", "port": 5432}, "debug": true, "tags": ["a", "b"], "empty": null}`))
+		case "/include":
+			fmt.Fprintf(w, "HOST=remote\n#include %s\n", localFile)
+		case "/slow":
+			time.Sleep(100 * time.Millisecond)
+		}
+	}))
+	defer srv.Close()
+
+	bearer := http.Header{"Authorization": {"Bearer token"}}
+
+	tests := []struct {
+		name    string
+		source  HTTPSource
+		want    map[string]string
+		wantErr string
+	}{
+		{"env", HTTPSource{URL: srv.URL + "/env", Header: bearer}, map[string]string{"HOST": "localhost", "PORT": "8080"}, ""},
+		{"json", HTTPSource{URL: srv.URL + "/json", Username: "u", Password: "p"}, map[string]string{"DB_HOST": "db", "DB_PORT": "5432", "DEBUG": "true", "TAGS": "a;b"}, ""},
+		{"include", HTTPSource{URL: srv.URL + "/include", Header: bearer}, nil, "include directives are not allowed here"},
+		{"unauthorized", HTTPSource{URL: srv.URL + "/env"}, nil, "unexpected status 401 Unauthorized"},
+		{"timeout", HTTPSource{URL: srv.URL + "/slow", Header: bearer, Timeout: 10 * time.Millisecond}, nil, "deadline exceeded"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.source.Load(context.Background())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Load() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
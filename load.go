@@ -0,0 +1,57 @@
+package envParser
+
+import (
+	"fmt"
+)
+
+// Load allocates a T, unmarshals os.Environ() into it and returns it.
+// T must be a struct type. On error the zero value of T is returned.
+func Load[T any]() (T, error) {
+	var v T
+	if err := UnmarshalFromEnv(&v); err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return v, nil
+}
+
+// LoadFromFile allocates a T, unmarshals the .env file at path merged with
+// the system environment into it and returns it. See UnmarshalFromFile.
+// T must be a struct type. On error the zero value of T is returned.
+func LoadFromFile[T any](path string) (T, error) {
+	var v T
+	if err := UnmarshalFromFile(path, &v); err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return v, nil
+}
+
+// MustLoad is like Load but panics if the configuration cannot be loaded.
+// It is intended for initialization in main.
+func MustLoad[T any]() T {
+	v, err := Load[T]()
+	if err != nil {
+		panic(fmt.Sprintf("envParser: load %T from environment: %v", v, err))
+	}
+
+	return v
+}
+
+// MustUnmarshalFromEnv is like UnmarshalFromEnv but panics on error.
+// It is intended for initialization in main.
+func MustUnmarshalFromEnv(v interface{}) {
+	if err := UnmarshalFromEnv(v); err != nil {
+		panic(fmt.Sprintf("envParser: unmarshal %T from environment: %v", v, err))
+	}
+}
+
+// MustUnmarshalFromFile is like UnmarshalFromFile but panics on error.
+// It is intended for initialization in main.
+func MustUnmarshalFromFile(path string, v interface{}) {
+	if err := UnmarshalFromFile(path, v); err != nil {
+		panic(fmt.Sprintf("envParser: unmarshal %T from %s: %v", v, path, err))
+	}
+}
@@ -0,0 +1,108 @@
+package envParser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	t.Setenv("LOAD_HOST", "localhost")
+	t.Setenv("LOAD_PORT", "9090")
+
+	type Config struct {
+		Host string `env:"LOAD_HOST,required"`
+		Port int    `env:"LOAD_PORT"`
+	}
+
+	cfg, err := Load[Config]()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Host != "localhost" || cfg.Port != 9090 {
+		t.Errorf("Load() = %+v", cfg)
+	}
+}
+
+func TestLoadError(t *testing.T) {
+	t.Setenv("LOAD_PORT", "not-a-number")
+
+	type Config struct {
+		Port int `env:"LOAD_PORT"`
+	}
+
+	cfg, err := Load[Config]()
+	if err == nil {
+		t.Fatal("expected error for invalid LOAD_PORT")
+	}
+	if cfg != (Config{}) {
+		t.Errorf("Load() = %+v, want zero value on error", cfg)
+	}
+
+	if _, err := Load[string](); err != ErrInvalidValue {
+		t.Errorf("Load[string]() error = %v, want ErrInvalidValue", err)
+	}
+}
+
+func TestLoadFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("LOAD_FILE_KEY=value\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	type Config struct {
+		Key string `env:"LOAD_FILE_KEY"`
+	}
+
+	cfg, err := LoadFromFile[Config](path)
+	if err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+	if cfg.Key != "value" {
+		t.Errorf("LoadFromFile() = %+v", cfg)
+	}
+
+	if _, err := LoadFromFile[Config](filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+func expectPanic(t *testing.T, name string, contains string, fn func()) {
+	t.Helper()
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Errorf("%s did not panic", name)
+			return
+		}
+		if msg, _ := r.(string); !strings.Contains(msg, contains) {
+			t.Errorf("%s panic = %v, want it to mention %q", name, r, contains)
+		}
+	}()
+	fn()
+}
+
+func TestMustVariants(t *testing.T) {
+	type Config struct {
+		Host string `env:"MUST_HOST,required"`
+	}
+
+	expectPanic(t, "MustLoad", "MUST_HOST", func() { MustLoad[Config]() })
+	expectPanic(t, "MustUnmarshalFromEnv", "MUST_HOST", func() { MustUnmarshalFromEnv(&Config{}) })
+	expectPanic(t, "MustUnmarshalFromFile", "missing.env", func() {
+		MustUnmarshalFromFile(filepath.Join(t.TempDir(), "missing.env"), &Config{})
+	})
+
+	t.Setenv("MUST_HOST", "localhost")
+
+	if cfg := MustLoad[Config](); cfg.Host != "localhost" {
+		t.Errorf("MustLoad() = %+v", cfg)
+	}
+
+	var cfg Config
+	MustUnmarshalFromEnv(&cfg)
+	if cfg.Host != "localhost" {
+		t.Errorf("MustUnmarshalFromEnv() = %+v", cfg)
+	}
+}
@@ -0,0 +1,413 @@
+package envParser
+
+import (
+	"encoding"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"net/url"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+
+// Marshal walks the env tags of v and returns the environment variables that
+// would unmarshal back into the same values. Slices and maps are joined with
+// their separators, nil pointers and file fields are omitted and types
+// implementing encoding.TextMarshaler are formatted with MarshalText.
+// v must be a struct or a non-nil pointer to a struct.
+func Marshal(v interface{}) (map[string]string, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, ErrInvalidValue
+		}
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return nil, ErrInvalidValue
+	}
+
+	envs := make(map[string]string)
+	if err := marshalStruct(envs, addressable(rv), scope{tag: Tag, auto: AutoKeys}); err != nil {
+		return nil, err
+	}
+
+	return envs, nil
+}
+
+// MarshalToFile marshals v and writes the result to path as a .env file with
+// one KEY=value line per variable, sorted by key. Values that would not read
+// back verbatim, such as multiline values or values with quotes, '#' or '$',
+// are double-quoted and escaped. The file is created with 0600 permissions
+// since configs often hold secrets.
+func MarshalToFile(path string, v interface{}) error {
+	envs, err := Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	var sb strings.Builder
+	for _, key := range slices.Sorted(maps.Keys(envs)) {
+		sb.WriteString(key)
+		sb.WriteByte('=')
+		sb.WriteString(quoteEnvValue(envs[key]))
+		sb.WriteByte('\n')
+	}
+
+	return os.WriteFile(path, []byte(sb.String()), 0o600)
+}
+
+// envValueQuoter escapes a value for a double-quoted .env value.
+var envValueQuoter = strings.NewReplacer(
+	`\`, `\\`,
+	`"`, `\"`,
+	"$", `\$`,
+	"\n", `\n`,
+	"\r", `\r`,
+	"\t", `\t`,
+)
+
+// quoteEnvValue returns value as written in a .env file, double-quoted if
+// it would otherwise be altered when read back.
+func quoteEnvValue(value string) string {
+	if !strings.ContainsAny(value, "\n\r\t\"'#$\\") && strings.TrimSpace(value) == value {
+		return value
+	}
+
+	return `"` + envValueQuoter.Replace(value) + `"`
+}
+
+func marshalStruct(envs map[string]string, rv reflect.Value, sc scope) error {
+	var err error
+
+	t := rv.Type()
+	for i := range rv.NumField() {
+		typeField := t.Field(i)
+		valueField := rv.Field(i)
+		if !typeField.IsExported() {
+			continue
+		}
+
+		tag := sc.fieldTag(typeField)
+		if tag == "-" {
+			continue
+		}
+
+		tf := sc.parseTag(tag)
+		if sc.recurse(typeField, tag) {
+			if mErr := marshalStruct(envs, valueField, sc.nested(typeField)); mErr != nil {
+				err = errors.Join(err, mErr)
+				continue
+			}
+		}
+
+		if tag == "" && isStructCollection(typeField.Type) && typeField.Tag.Get(PrefixTag) != "" {
+			var mErr error
+			if valueField.Kind() == reflect.Slice {
+				mErr = marshalStructSlice(envs, valueField, sc.nested(typeField))
+			} else {
+				mErr = marshalStructMap(envs, valueField, sc.nested(typeField))
+			}
+			if mErr != nil {
+				err = errors.Join(err, mErr)
+			}
+			continue
+		}
+
+		// The value of a file field is a path; the contents it was read from
+		// are a secret that would not unmarshal back, so it is skipped.
+		if tag == "" || tf.File {
+			continue
+		}
+
+		var (
+			value string
+			ok    bool
+			fErr  error
+		)
+		switch {
+		case tf.Struct:
+			value, ok, fErr = formatCompactStruct(valueField, tf, sc)
+		case tf.JSON:
+			value, ok, fErr = formatJSON(valueField)
+		case valueField.Kind() == reflect.Interface:
+			value, ok, fErr = formatImplementation(envs, valueField, sc)
+		default:
+			value, ok, fErr = format(valueField, tf)
+		}
+
+		if fErr != nil {
+			err = errors.Join(err, fmt.Errorf("field %s: %w", typeField.Name, fErr))
+			continue
+		}
+
+		if ok {
+			envs[tf.Key] = value
+		}
+	}
+
+	return err
+}
+
+// marshalStructSlice writes element i of the struct slice f under sc.prefix
+// followed by "i_", the inverse of unmarshalStructSlice. Nil elements are
+// skipped without leaving a gap in the indices.
+func marshalStructSlice(envs map[string]string, f reflect.Value, sc scope) error {
+	var err error
+	index := 0
+	for i := range f.Len() {
+		elem := f.Index(i)
+		if elem.Kind() == reflect.Ptr {
+			if elem.IsNil() {
+				continue
+			}
+			elem = elem.Elem()
+		}
+
+		elemScope := sc.element(strconv.Itoa(index))
+		index++
+		if mErr := marshalStruct(envs, addressable(elem), elemScope); mErr != nil {
+			err = errors.Join(err, mErr)
+		}
+	}
+
+	return err
+}
+
+// marshalStructMap writes the element for each key NAME of the struct map f
+// under sc.prefix followed by "NAME_", the inverse of unmarshalStructMap.
+// Nil elements are skipped.
+func marshalStructMap(envs map[string]string, f reflect.Value, sc scope) error {
+	var err error
+	for _, key := range f.MapKeys() {
+		elem := f.MapIndex(key)
+		if elem.Kind() == reflect.Ptr {
+			if elem.IsNil() {
+				continue
+			}
+			elem = elem.Elem()
+		}
+
+		elemScope := sc.element(key.String())
+		if mErr := marshalStruct(envs, addressable(elem), elemScope); mErr != nil {
+			err = errors.Join(err, mErr)
+		}
+	}
+
+	return err
+}
+
+// format returns the env representation of f. ok is false for nil pointers,
+// which are omitted from the output.
+func format(f reflect.Value, tf tagField) (value string, ok bool, err error) {
+	if f.Kind() == reflect.Ptr {
+		if f.IsNil() {
+			return "", false, nil
+		}
+		return format(f.Elem(), tf)
+	}
+
+	if f.Type() == timeType {
+		return formatTime(f.Interface().(time.Time), tf.Layout), true, nil
+	}
+
+	if f.Type() == urlType {
+		u := f.Interface().(url.URL)
+		return u.String(), true, nil
+	}
+
+	if f.Type() == ipNetType {
+		network := f.Interface().(net.IPNet)
+		return network.String(), true, nil
+	}
+
+	f = addressable(f)
+	if f.Addr().Type().Implements(textMarshalerType) {
+		text, err := f.Addr().Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err == nil, err
+	}
+
+	t := f.Type()
+	if tf.Encoding != "" && isBytes(t) {
+		if f.IsNil() {
+			return "", false, nil
+		}
+		value, err := encodeBytes(f.Bytes(), tf.Encoding)
+		return value, err == nil, err
+	}
+
+	if tf.Encoding != "" && t.Kind() == reflect.String {
+		value, err := encodeBytes([]byte(f.String()), tf.Encoding)
+		return value, err == nil, err
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return f.String(), true, nil
+	case reflect.Bool:
+		return strconv.FormatBool(f.Bool()), true, nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(f.Float(), 'g', -1, t.Bits()), true, nil
+	case reflect.Complex64, reflect.Complex128:
+		return strconv.FormatComplex(f.Complex(), 'g', -1, t.Bits()), true, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if t.PkgPath() == "time" && t.Name() == "Duration" {
+			return time.Duration(f.Int()).String(), true, nil
+		}
+		return strconv.FormatInt(f.Int(), 10), true, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(f.Uint(), 10), true, nil
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && f.IsNil() {
+			return "", false, nil
+		}
+
+		values := make([]string, f.Len())
+		for i := range f.Len() {
+			v, _, err := format(f.Index(i), tf.inner())
+			if err != nil {
+				return "", false, err
+			}
+			values[i] = v
+		}
+		return strings.Join(values, tf.separator()), true, nil
+	case reflect.Map:
+		if f.IsNil() {
+			return "", false, nil
+		}
+
+		pairs := make([]string, 0, f.Len())
+		for _, key := range f.MapKeys() {
+			k, _, err := format(key, tagField{})
+			if err != nil {
+				return "", false, err
+			}
+			v, _, err := format(f.MapIndex(key), tf.inner())
+			if err != nil {
+				return "", false, err
+			}
+			pairs = append(pairs, k+tf.kvSeparator()+v)
+		}
+		slices.Sort(pairs)
+		return strings.Join(pairs, tf.separator()), true, nil
+	default:
+		return "", false, ErrUnsupportedType
+	}
+}
+
+// formatJSON encodes f as JSON, the inverse of the json tag option. ok is
+// false for nil pointers, slices and maps, which are omitted from the output.
+func formatJSON(f reflect.Value) (string, bool, error) {
+	switch f.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface:
+		if f.IsNil() {
+			return "", false, nil
+		}
+	}
+
+	data, err := json.Marshal(f.Interface())
+	if err != nil {
+		return "", false, err
+	}
+
+	return string(data), true, nil
+}
+
+// formatCompactStruct encodes the nested struct f as "key=value,key=value",
+// the inverse of the struct tag option.
+func formatCompactStruct(f reflect.Value, tf tagField, sc scope) (string, bool, error) {
+	if f.Kind() != reflect.Struct {
+		return "", false, ErrUnsupportedType
+	}
+
+	pairs := make(map[string]string)
+	if err := marshalStruct(pairs, f, scope{tag: sc.tag, auto: sc.auto}); err != nil {
+		return "", false, err
+	}
+
+	sep := ","
+	if tf.Separator != "" {
+		sep = tf.Separator
+	}
+
+	entries := make([]string, 0, len(pairs))
+	for _, key := range slices.Sorted(maps.Keys(pairs)) {
+		entries = append(entries, key+"="+pairs[key])
+	}
+
+	return strings.Join(entries, sep), true, nil
+}
+
+// formatImplementation returns the name under which the concrete type of the
+// interface field f is registered and marshals the implementation's fields.
+func formatImplementation(envs map[string]string, f reflect.Value, sc scope) (string, bool, error) {
+	if f.IsNil() {
+		return "", false, nil
+	}
+
+	impl := f.Elem()
+	name, ok := implementationName(f.Type(), impl.Type())
+	if !ok {
+		return "", false, fmt.Errorf("%w: no implementation registered for %s", ErrUnsupportedType, impl.Type())
+	}
+
+	if impl.Kind() == reflect.Ptr && impl.Elem().Kind() == reflect.Struct {
+		if err := marshalStruct(envs, impl.Elem(), sc); err != nil {
+			return "", false, err
+		}
+	}
+
+	return name, true, nil
+}
+
+// addressable returns v itself if it is addressable, or an addressable copy.
+func addressable(v reflect.Value) reflect.Value {
+	if v.CanAddr() {
+		return v
+	}
+
+	c := reflect.New(v.Type()).Elem()
+	c.Set(v)
+	return c
+}
+
+// formatTime formats t with layout, the inverse of parseTime.
+func formatTime(t time.Time, layout string) string {
+	switch layout {
+	case "":
+		return t.Format(time.RFC3339Nano)
+	case layoutUnix:
+		return strconv.FormatInt(t.Unix(), 10)
+	case layoutUnixMilli:
+		return strconv.FormatInt(t.UnixMilli(), 10)
+	default:
+		return t.Format(layout)
+	}
+}
+
+// encodeBytes encodes b with encoding, the inverse of decodeBytes.
+func encodeBytes(b []byte, encoding string) (string, error) {
+	switch encoding {
+	case "raw":
+		return string(b), nil
+	case "hex":
+		return hex.EncodeToString(b), nil
+	case "base64":
+		return base64.StdEncoding.EncodeToString(b), nil
+	case "base64url":
+		return base64.URLEncoding.EncodeToString(b), nil
+	default:
+		return "", fmt.Errorf("invalid encoding: %s", encoding)
+	}
+}
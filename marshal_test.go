@@ -0,0 +1,383 @@
+package envParser
+
+import (
+	"net"
+	"net/netip"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMarshal(t *testing.T) {
+	type Conn struct {
+		Host string `env:"HOST"`
+		Port int    `env:"PORT"`
+	}
+	type Config struct {
+		Name     string            `env:"NAME"`
+		Debug    bool              `env:"DEBUG"`
+		Rate     float64           `env:"RATE"`
+		Retries  uint8             `env:"RETRIES"`
+		Timeout  time.Duration     `env:"TIMEOUT"`
+		Hosts    []string          `env:"HOSTS,separator=|"`
+		Ports    []int             `env:"PORTS"`
+		Labels   map[string]string `env:"LABELS"`
+		Addr     netip.Addr        `env:"ADDR"`
+		Optional *string           `env:"OPTIONAL"`
+		Compact  Conn              `env:"COMPACT,struct"`
+		DB       Conn              `envPrefix:"DB_"`
+		NoTag    string
+	}
+
+	cfg := Config{
+		Name:    "app",
+		Debug:   true,
+		Rate:    1.5,
+		Retries: 3,
+		Timeout: 90 * time.Second,
+		Hosts:   []string{"a", "b"},
+		Ports:   []int{80, 443},
+		Labels:  map[string]string{"region": "us", "env": "prod"},
+		Addr:    netip.MustParseAddr("10.0.0.1"),
+		Compact: Conn{Host: "h", Port: 1},
+		DB:      Conn{Host: "db", Port: 5432},
+		NoTag:   "ignored",
+	}
+
+	envs, err := Marshal(cfg)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	want := map[string]string{
+		"NAME":    "app",
+		"DEBUG":   "true",
+		"RATE":    "1.5",
+		"RETRIES": "3",
+		"TIMEOUT": "1m30s",
+		"HOSTS":   "a|b",
+		"PORTS":   "80;443",
+		"LABELS":  "env:prod;region:us",
+		"ADDR":    "10.0.0.1",
+		"COMPACT": "HOST=h,PORT=1",
+		"DB_HOST": "db",
+		"DB_PORT": "5432",
+	}
+	if !reflect.DeepEqual(envs, want) {
+		t.Errorf("Marshal() = %v\nwant %v", envs, want)
+	}
+
+	var back Config
+	if err := Unmarshal(envs, &back); err != nil {
+		t.Fatalf("Unmarshal() of marshaled env error = %v", err)
+	}
+	cfg.NoTag = ""
+	if !reflect.DeepEqual(back, cfg) {
+		t.Errorf("round trip = %+v\nwant %+v", back, cfg)
+	}
+}
+
+func TestMarshalImplementation(t *testing.T) {
+	RegisterImplementation(reflect.TypeFor[testStorage](), "disk", func() interface{} { return &testDiskStorage{} })
+
+	type Config struct {
+		Store testStorage `env:"STORE_TYPE"`
+	}
+
+	envs, err := Marshal(&Config{Store: &testDiskStorage{Path: "/data"}})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if envs["STORE_TYPE"] != "disk" || envs["DISK_PATH"] != "/data" {
+		t.Errorf("Marshal() = %v", envs)
+	}
+}
+
+func TestMarshalErrors(t *testing.T) {
+	if _, err := Marshal(nil); err != ErrInvalidValue {
+		t.Errorf("Marshal(nil) error = %v, want ErrInvalidValue", err)
+	}
+
+	type Config struct {
+		Ch chan int `env:"CH"`
+	}
+	if _, err := Marshal(Config{Ch: make(chan int)}); err == nil {
+		t.Error("expected error for unsupported type")
+	}
+}
+
+func TestMarshalToFile(t *testing.T) {
+	type Config struct {
+		Port int    `env:"PORT"`
+		Host string `env:"HOST"`
+	}
+
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := MarshalToFile(path, Config{Port: 8080, Host: "localhost"}); err != nil {
+		t.Fatalf("MarshalToFile() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "HOST=localhost\nPORT=8080\n" {
+		t.Errorf("file content = %q", data)
+	}
+
+	var back Config
+	if err := UnmarshalFromFileOnly(path, &back); err != nil {
+		t.Fatalf("UnmarshalFromFileOnly() error = %v", err)
+	}
+	if back.Port != 8080 || back.Host != "localhost" {
+		t.Errorf("round trip = %+v", back)
+	}
+}
+
+func TestMarshalToFileRoundTrip(t *testing.T) {
+	defer func() { ExpandVariables = false }()
+
+	type Config struct {
+		Multi   string `env:"MULTI"`
+		Comment string `env:"COMMENT"`
+		Quotes  string `env:"QUOTES"`
+		Dollar  string `env:"DOLLAR"`
+		Slash   string `env:"SLASH"`
+		Padded  string `env:"PADDED"`
+		Plain   string `env:"PLAIN"`
+	}
+
+	want := Config{
+		Multi:   "line1\nline2\r\n",
+		Comment: "x #y",
+		Quotes:  `'single' and "double"`,
+		Dollar:  "$HOME and ${PATH}",
+		Slash:   `C:\dir\n`,
+		Padded:  "  padded\t",
+		Plain:   "localhost",
+	}
+
+	for _, expand := range []bool{false, true} {
+		ExpandVariables = expand
+
+		path := filepath.Join(t.TempDir(), ".env")
+		if err := MarshalToFile(path, want); err != nil {
+			t.Fatalf("MarshalToFile() error = %v", err)
+		}
+
+		envs, err := ReadEnvFiles(path)
+		if err != nil {
+			t.Fatalf("ReadEnvFiles() error = %v", err)
+		}
+
+		var got Config
+		if err := Unmarshal(envs, &got); err != nil {
+			t.Fatalf("Unmarshal() error = %v", err)
+		}
+		if got != want {
+			t.Errorf("round trip with ExpandVariables=%v = %+v, want %+v", expand, got, want)
+		}
+	}
+}
+
+func TestMarshalTime(t *testing.T) {
+	type Config struct {
+		Start time.Time `env:"START"`
+		Date  time.Time `env:"DATE,layout=2006-01-02"`
+		Unix  time.Time `env:"UNIX,layout=unix"`
+	}
+
+	at := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	envs, err := Marshal(Config{Start: at, Date: at, Unix: at})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	want := map[string]string{"START": "2024-03-01T12:30:00Z", "DATE": "2024-03-01", "UNIX": "1709296200"}
+	for k, v := range want {
+		if envs[k] != v {
+			t.Errorf("%s = %q, want %q", k, envs[k], v)
+		}
+	}
+}
+
+func TestMarshalURL(t *testing.T) {
+	type Config struct {
+		API     url.URL  `env:"API_URL"`
+		Webhook *url.URL `env:"WEBHOOK_URL"`
+	}
+
+	api, _ := url.Parse("https://api.example.com/v1?debug=1")
+	envs, err := Marshal(Config{API: *api})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if envs["API_URL"] != "https://api.example.com/v1?debug=1" {
+		t.Errorf("API_URL = %q", envs["API_URL"])
+	}
+	if _, ok := envs["WEBHOOK_URL"]; ok {
+		t.Error("nil WEBHOOK_URL should be omitted")
+	}
+}
+
+func TestMarshalIPNet(t *testing.T) {
+	type Config struct {
+		Network net.IPNet `env:"NETWORK"`
+	}
+
+	_, network, _ := net.ParseCIDR("10.1.0.0/16")
+	envs, err := Marshal(Config{Network: *network})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if envs["NETWORK"] != "10.1.0.0/16" {
+		t.Errorf("NETWORK = %q", envs["NETWORK"])
+	}
+}
+
+func TestMarshalBytesEncoding(t *testing.T) {
+	type Config struct {
+		Key    []byte `env:"KEY,encoding=base64"`
+		Hex    []byte `env:"HEX,encoding=hex"`
+		Unset  []byte `env:"UNSET,encoding=hex"`
+		Secret []byte `env:"SECRET,encoding=raw"`
+		Text   string `env:"TEXT,base64"`
+	}
+
+	in := Config{Key: []byte("hi??>>"), Hex: []byte{0xde, 0xad}, Secret: []byte("s3cr3t"), Text: "p@$$w;rd"}
+	envs, err := Marshal(in)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if envs["KEY"] != "aGk/Pz4+" || envs["HEX"] != "dead" || envs["SECRET"] != "s3cr3t" || envs["TEXT"] != "cEAkJHc7cmQ=" {
+		t.Errorf("Marshal() = %v", envs)
+	}
+	if _, ok := envs["UNSET"]; ok {
+		t.Error("nil UNSET should be omitted")
+	}
+
+	var out Config
+	if err := Unmarshal(envs, &out); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("round trip = %+v, want %+v", out, in)
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	type Upstream struct {
+		Name string `json:"name"`
+	}
+
+	type Config struct {
+		Upstreams []Upstream      `env:"UPSTREAMS,json"`
+		Primary   Upstream        `env:"PRIMARY,json"`
+		Features  map[string]bool `env:"FEATURES,json"`
+	}
+
+	in := Config{Upstreams: []Upstream{{Name: "a"}}, Primary: Upstream{Name: "main"}}
+	envs, err := Marshal(in)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if envs["UPSTREAMS"] != `[{"name":"a"}]` || envs["PRIMARY"] != `{"name":"main"}` {
+		t.Errorf("Marshal() = %v", envs)
+	}
+	if _, ok := envs["FEATURES"]; ok {
+		t.Error("nil FEATURES should be omitted")
+	}
+
+	var out Config
+	if err := Unmarshal(envs, &out); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("round trip = %+v, want %+v", out, in)
+	}
+}
+
+func TestMarshalStructSlice(t *testing.T) {
+	type Server struct {
+		Host string `env:"HOST"`
+	}
+
+	type Config struct {
+		Servers []*Server `envPrefix:"SERVERS_"`
+	}
+
+	envs, err := Marshal(Config{Servers: []*Server{{Host: "a"}, nil, {Host: "b"}}})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	want := map[string]string{"SERVERS_0_HOST": "a", "SERVERS_1_HOST": "b"}
+	if !reflect.DeepEqual(envs, want) {
+		t.Errorf("Marshal() = %v, want %v", envs, want)
+	}
+}
+
+func TestMarshalStructMap(t *testing.T) {
+	type Upstream struct {
+		URL string `env:"URL"`
+	}
+
+	type Config struct {
+		Upstreams map[string]*Upstream `envPrefix:"UPSTREAMS_"`
+	}
+
+	in := Config{Upstreams: map[string]*Upstream{"API": {URL: "http://api"}, "WEB": {URL: "http://web"}, "NONE": nil}}
+	envs, err := Marshal(in)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	want := map[string]string{"UPSTREAMS_API_URL": "http://api", "UPSTREAMS_WEB_URL": "http://web"}
+	if !reflect.DeepEqual(envs, want) {
+		t.Errorf("Marshal() = %v, want %v", envs, want)
+	}
+}
+
+func TestMarshalMapKeyTypes(t *testing.T) {
+	type Config struct {
+		Ports map[int]string `env:"PORTS"`
+	}
+
+	in := Config{Ports: map[int]string{443: "https", 80: "http"}}
+	envs, err := Marshal(in)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if envs["PORTS"] != "443:https;80:http" {
+		t.Errorf("PORTS = %q", envs["PORTS"])
+	}
+
+	var out Config
+	if err := Unmarshal(envs, &out); err != nil || !reflect.DeepEqual(in, out) {
+		t.Errorf("round trip = %+v, %v", out, err)
+	}
+}
+
+func TestMarshalSkipField(t *testing.T) {
+	type Cache struct {
+		Size int `env:"SIZE"`
+	}
+
+	type Config struct {
+		Host     string `env:"HOST"`
+		Cache    Cache  `env:"-"`
+		Token    string `env:"-"`
+		Password string `env:"DB_PASSWORD,file"`
+	}
+
+	envs, err := Marshal(Config{Host: "localhost", Cache: Cache{Size: 1}, Token: "computed", Password: "hunter2"})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if want := map[string]string{"HOST": "localhost"}; !reflect.DeepEqual(envs, want) {
+		t.Errorf("Marshal() = %v, want %v", envs, want)
+	}
+}
@@ -0,0 +1,81 @@
+package envParser
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+// Metrics holds statistics collected while unmarshaling a struct.
+type Metrics struct {
+	// Fields is the number of tagged fields processed.
+	Fields int
+	// Defaults is the number of fields that fell back to their default value.
+	Defaults int
+	// Errors is the number of errors encountered, including validation.
+	Errors int
+	// Duration is the total time spent unmarshaling and validating.
+	Duration time.Duration
+	// Timings holds the time spent parsing each field value, in parse order.
+	// Time spent in nested implementations is included in the parent field.
+	Timings []FieldTiming
+}
+
+// FieldTiming is the time spent parsing the value of a single field.
+type FieldTiming struct {
+	Key      string
+	Duration time.Duration
+}
+
+// Slowest returns up to n field timings, slowest first. It returns nil if
+// n is not positive.
+func (m Metrics) Slowest(n int) []FieldTiming {
+	if n <= 0 {
+		return nil
+	}
+
+	timings := slices.Clone(m.Timings)
+	slices.SortStableFunc(timings, func(a, b FieldTiming) int {
+		return cmp.Compare(b.Duration, a.Duration)
+	})
+
+	return timings[:min(n, len(timings))]
+}
+
+// UnmarshalWithMetrics behaves like Unmarshal and additionally returns
+// metrics about the parse. Metrics are returned even when an error occurs.
+func UnmarshalWithMetrics(envs map[string]string, v interface{}) (Metrics, error) {
+	var m Metrics
+
+	start := time.Now()
+	d := newDecoder(DefaultOptions())
+	d.metrics = &m
+	err := d.decode(envs, v)
+	m.Duration = time.Since(start)
+
+	return m, err
+}
+
+func (m *Metrics) addField() {
+	if m != nil {
+		m.Fields++
+	}
+}
+
+func (m *Metrics) addDefault() {
+	if m != nil {
+		m.Defaults++
+	}
+}
+
+func (m *Metrics) addError() {
+	if m != nil {
+		m.Errors++
+	}
+}
+
+func (m *Metrics) addTiming(key string, d time.Duration) {
+	if m != nil {
+		m.Timings = append(m.Timings, FieldTiming{Key: key, Duration: d})
+	}
+}
@@ -0,0 +1,156 @@
+package envParser
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestUnmarshalWithMetrics(t *testing.T) {
+	type DB struct {
+		Host string `env:"DB_HOST,required"`
+		Port int    `env:"DB_PORT,default=5432"`
+	}
+	type Config struct {
+		Name    string `env:"NAME"`
+		Port    int    `env:"PORT,default=8080"`
+		Count   int    `env:"COUNT"`
+		Missing string `env:"MISSING"`
+		NoTag   string
+		DB      DB
+	}
+
+	envs := map[string]string{
+		"NAME":    "app",
+		"COUNT":   "not-a-number",
+		"DB_HOST": "localhost",
+	}
+
+	var cfg Config
+	m, err := UnmarshalWithMetrics(envs, &cfg)
+	if err == nil {
+		t.Fatal("expected error for invalid COUNT")
+	}
+
+	if m.Fields != 6 {
+		t.Errorf("Fields = %d, want 6", m.Fields)
+	}
+	if m.Defaults != 2 {
+		t.Errorf("Defaults = %d, want 2", m.Defaults)
+	}
+	if m.Errors != 1 {
+		t.Errorf("Errors = %d, want 1", m.Errors)
+	}
+	if m.Duration <= 0 {
+		t.Errorf("Duration = %v, want > 0", m.Duration)
+	}
+}
+
+func TestUnmarshalWithMetricsNestedError(t *testing.T) {
+	type Addr struct {
+		Port int `env:"port"`
+	}
+	type Config struct {
+		Addr Addr `env:"ADDR,struct"`
+	}
+
+	var cfg Config
+	m, err := UnmarshalWithMetrics(map[string]string{"ADDR": "port=abc"}, &cfg)
+	if err == nil {
+		t.Fatal("expected error for invalid port")
+	}
+
+	if m.Errors != 1 {
+		t.Errorf("Errors = %d, want 1", m.Errors)
+	}
+}
+
+func TestUnmarshalWithMetricsValidatorError(t *testing.T) {
+	defer SetValidator(nil)
+	SetValidator(&mockValidator{err: errors.New("validation failed")})
+
+	type Config struct {
+		Name string `env:"NAME"`
+	}
+
+	var cfg Config
+	m, err := UnmarshalWithMetrics(map[string]string{"NAME": "app"}, &cfg)
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+
+	if m.Fields != 1 || m.Errors != 1 {
+		t.Errorf("metrics = %+v, want 1 field and 1 error", m)
+	}
+}
+
+type slowService interface {
+	Ready() bool
+}
+
+type slowServiceImpl struct{}
+
+func (s *slowServiceImpl) Ready() bool { return true }
+
+func TestMetricsSlowest(t *testing.T) {
+	RegisterImplementation(reflect.TypeFor[slowService](), "slow", func() interface{} {
+		time.Sleep(20 * time.Millisecond)
+		return &slowServiceImpl{}
+	})
+
+	type Config struct {
+		Name    string        `env:"NAME"`
+		Service slowService   `env:"SERVICE"`
+		Port    int           `env:"PORT,default=8080"`
+		Timeout time.Duration `env:"TIMEOUT,default=1s"`
+	}
+
+	var cfg Config
+	m, err := UnmarshalWithMetrics(map[string]string{"NAME": "app", "SERVICE": "slow"}, &cfg)
+	if err != nil {
+		t.Fatalf("UnmarshalWithMetrics() error = %v", err)
+	}
+
+	if len(m.Timings) != 4 {
+		t.Fatalf("Timings = %v, want 4 entries", m.Timings)
+	}
+
+	slowest := m.Slowest(2)
+	if len(slowest) != 2 || slowest[0].Key != "SERVICE" || slowest[0].Duration < 20*time.Millisecond {
+		t.Errorf("Slowest(2) = %v, want SERVICE first", slowest)
+	}
+	if len(m.Slowest(10)) != 4 {
+		t.Errorf("Slowest(10) should return all %d timings", len(m.Timings))
+	}
+	for _, n := range []int{0, -1} {
+		if got := m.Slowest(n); got != nil {
+			t.Errorf("Slowest(%d) = %v, want nil", n, got)
+		}
+	}
+}
+
+func TestMetricsSlowestRegisteredParser(t *testing.T) {
+	type slowValue string
+	slowType := reflect.TypeFor[slowValue]()
+	RegisterParser(slowType, func(s string) (interface{}, error) {
+		time.Sleep(20 * time.Millisecond)
+		return slowValue(s), nil
+	})
+	defer RegisterParser(slowType, nil)
+
+	type Config struct {
+		Fast string    `env:"FAST"`
+		Slow slowValue `env:"SLOW"`
+	}
+
+	var cfg Config
+	m, err := UnmarshalWithMetrics(map[string]string{"FAST": "a", "SLOW": "b"}, &cfg)
+	if err != nil {
+		t.Fatalf("UnmarshalWithMetrics() error = %v", err)
+	}
+
+	if slowest := m.Slowest(1); len(slowest) != 1 || slowest[0].Key != "SLOW" {
+		t.Errorf("Slowest(1) = %v, want SLOW", slowest)
+	}
+}
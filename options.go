@@ -0,0 +1,99 @@
+package envParser
+
+import (
+	"strings"
+)
+
+// Options configures a single unmarshal call without touching the
+// package-level configuration. The zero value uses the "env" tag, the ";"
+// and ":" separators and no validator, regardless of the package-level
+// settings.
+type Options struct {
+	// Tag is the struct tag key used to identify environment variable names.
+	// Defaults to "env".
+	Tag string
+	// Separator is the default separator used for slice and map values.
+	// Defaults to ";".
+	Separator string
+	// KeyValueSeparator is the default separator between keys and values of
+	// map entries. Defaults to ":".
+	KeyValueSeparator string
+	// TrimElements trims whitespace from slice elements and map entries.
+	TrimElements bool
+	// Prefix is prepended to every key, e.g. "MYAPP_".
+	Prefix string
+	// RequiredIfNoDefault treats every tagged field without a default as required.
+	RequiredIfNoDefault bool
+	// Strict enables additional tag checks, see the package-level Strict.
+	Strict bool
+	// LenientBools accepts yes/no, on/off and similar bool forms, see the
+	// package-level LenientBools.
+	LenientBools bool
+	// AutoKeys derives keys for untagged fields, see the package-level AutoKeys.
+	AutoKeys bool
+	// DisallowUnknownKeys fails for keys no field consumed, see the
+	// package-level DisallowUnknownKeys.
+	DisallowUnknownKeys bool
+	// ConsumeKeys deletes consumed keys from the input map, see the
+	// package-level ConsumeKeys.
+	ConsumeKeys bool
+	// FailFast stops at the first failing field, see the package-level FailFast.
+	FailFast bool
+	// SecretsDir is a directory of secret files to read, see the
+	// package-level SecretsDir.
+	SecretsDir string
+	// KeyNormalizeReplacer is applied to environment and tag keys before matching.
+	KeyNormalizeReplacer *strings.Replacer
+	// Validator is called with the struct pointer after all fields are set.
+	Validator Validator
+	// UnknownKeyHook is called for every key left unconsumed after unmarshaling.
+	UnknownKeyHook func(key, value string)
+	// WarningHook is called for every deprecated key that is used.
+	WarningHook func(key, message string)
+}
+
+// DefaultOptions returns Options reflecting the current package-level
+// configuration (Tag, Separator, KeyValueSeparator, TrimElements, Strict,
+// LenientBools, AutoKeys, DisallowUnknownKeys, ConsumeKeys, FailFast,
+// SecretsDir, KeyNormalizeReplacer, SetValidator, SetUnknownKeyHook and
+// SetWarningHook). This is what Unmarshal uses.
+func DefaultOptions() Options {
+	return Options{
+		Tag:                  Tag,
+		Separator:            Separator,
+		KeyValueSeparator:    KeyValueSeparator,
+		TrimElements:         TrimElements,
+		Strict:               Strict,
+		LenientBools:         LenientBools,
+		AutoKeys:             AutoKeys,
+		DisallowUnknownKeys:  DisallowUnknownKeys,
+		ConsumeKeys:          ConsumeKeys,
+		FailFast:             FailFast,
+		SecretsDir:           SecretsDir,
+		KeyNormalizeReplacer: KeyNormalizeReplacer,
+		Validator:            getValidator(),
+		UnknownKeyHook:       getUnknownKeyHook(),
+		WarningHook:          getWarningHook(),
+	}
+}
+
+// UnmarshalWithOptions parses environment variables from a map into v using
+// opts instead of the package-level configuration.
+// v must be a non-nil pointer to a struct.
+func UnmarshalWithOptions(envs map[string]string, v interface{}, opts Options) error {
+	return newDecoder(opts).decode(envs, v)
+}
+
+func newDecoder(opts Options) *decoder {
+	if opts.Tag == "" {
+		opts.Tag = "env"
+	}
+	if opts.Separator == "" {
+		opts.Separator = ";"
+	}
+	if opts.KeyValueSeparator == "" {
+		opts.KeyValueSeparator = ":"
+	}
+
+	return &decoder{opts: opts, matched: make(map[string]bool)}
+}
@@ -0,0 +1,266 @@
+package envParser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestUnmarshalWithOptions(t *testing.T) {
+	type Config struct {
+		Host  string   `cfg:"HOST"`
+		Hosts []string `cfg:"HOSTS"`
+		Port  int      `cfg:"PORT,default=8080"`
+	}
+
+	envs := map[string]string{
+		"APP_HOST":  "localhost",
+		"APP_HOSTS": "a,b",
+		"HOST":      "unprefixed",
+	}
+	opts := Options{Tag: "cfg", Separator: ",", Prefix: "APP_"}
+
+	var cfg Config
+	if err := UnmarshalWithOptions(envs, &cfg, opts); err != nil {
+		t.Fatalf("UnmarshalWithOptions() error = %v", err)
+	}
+
+	if cfg.Host != "localhost" || len(cfg.Hosts) != 2 || cfg.Port != 8080 {
+		t.Errorf("UnmarshalWithOptions() = %+v", cfg)
+	}
+}
+
+func TestUnmarshalWithOptionsIgnoresGlobals(t *testing.T) {
+	defer SetValidator(nil)
+	defer func() { Tag, Separator = "env", ";" }()
+
+	SetValidator(&mockValidator{err: errors.New("global validator")})
+	Tag = "other"
+	Separator = "|"
+
+	type Config struct {
+		Items []string `env:"ITEMS"`
+	}
+
+	var cfg Config
+	if err := UnmarshalWithOptions(map[string]string{"ITEMS": "a;b"}, &cfg, Options{}); err != nil {
+		t.Fatalf("UnmarshalWithOptions() error = %v", err)
+	}
+
+	if len(cfg.Items) != 2 {
+		t.Errorf("Items = %v, want built-in defaults to apply", cfg.Items)
+	}
+}
+
+func TestUnmarshalWithOptionsKeyValueSeparator(t *testing.T) {
+	type Config struct {
+		Labels map[string]string `env:"LABELS"`
+	}
+
+	var cfg Config
+	opts := Options{KeyValueSeparator: "="}
+	if err := UnmarshalWithOptions(map[string]string{"LABELS": "a=1;b=2"}, &cfg, opts); err != nil {
+		t.Fatalf("UnmarshalWithOptions() error = %v", err)
+	}
+	if cfg.Labels["a"] != "1" || cfg.Labels["b"] != "2" {
+		t.Errorf("Labels = %v", cfg.Labels)
+	}
+}
+
+func TestUnmarshalWithOptionsValidatorAndHook(t *testing.T) {
+	type Config struct {
+		Name string `env:"NAME"`
+	}
+
+	mv := &mockValidator{}
+	var unknown []string
+	opts := Options{
+		Validator:      mv,
+		UnknownKeyHook: func(key, value string) { unknown = append(unknown, key) },
+	}
+
+	var cfg Config
+	if err := UnmarshalWithOptions(map[string]string{"NAME": "x", "EXTRA": "y"}, &cfg, opts); err != nil {
+		t.Fatalf("UnmarshalWithOptions() error = %v", err)
+	}
+
+	if !mv.called {
+		t.Error("Options.Validator was not called")
+	}
+	if len(unknown) != 1 || unknown[0] != "EXTRA" {
+		t.Errorf("unknown = %v, want [EXTRA]", unknown)
+	}
+}
+
+func TestUnmarshalWithOptionsRequiredIfNoDefault(t *testing.T) {
+	type Config struct {
+		Host string `env:"HOST"`
+		Port int    `env:"PORT,default=8080"`
+	}
+
+	var cfg Config
+	err := UnmarshalWithOptions(map[string]string{}, &cfg, Options{RequiredIfNoDefault: true})
+	if err == nil || !strings.Contains(err.Error(), "HOST") || strings.Contains(err.Error(), "PORT") {
+		t.Errorf("expected required error for HOST only, got: %v", err)
+	}
+}
+
+func TestUnmarshalWithOptionsStrict(t *testing.T) {
+	type Config struct {
+		Host string `env:"HOST,separator=|"`
+	}
+
+	var cfg Config
+	err := UnmarshalWithOptions(map[string]string{"HOST": "x"}, &cfg, Options{Strict: true})
+	if !errors.Is(err, ErrInvalidTagOption) {
+		t.Errorf("expected ErrInvalidTagOption, got: %v", err)
+	}
+}
+
+func TestDefaultOptions(t *testing.T) {
+	defer func() { Strict = false }()
+	Strict = true
+
+	opts := DefaultOptions()
+	if opts.Tag != Tag || opts.Separator != Separator || !opts.Strict {
+		t.Errorf("DefaultOptions() = %+v", opts)
+	}
+}
+
+func TestUnmarshalWithOptionsLenientBools(t *testing.T) {
+	type Config struct {
+		Debug bool `env:"DEBUG"`
+	}
+
+	var cfg Config
+	if err := UnmarshalWithOptions(map[string]string{"DEBUG": "off"}, &cfg, Options{}); err == nil {
+		t.Error("expected error without LenientBools")
+	}
+	if err := UnmarshalWithOptions(map[string]string{"DEBUG": "on"}, &cfg, Options{LenientBools: true}); err != nil || !cfg.Debug {
+		t.Errorf("UnmarshalWithOptions() = %+v, %v", cfg, err)
+	}
+}
+
+func TestUnmarshalWithOptionsWarningHook(t *testing.T) {
+	type Config struct {
+		Old string `env:"OLD,deprecated=use NEW"`
+	}
+
+	var got []string
+	opts := Options{WarningHook: func(key, message string) { got = append(got, key+": "+message) }}
+
+	var cfg Config
+	if err := UnmarshalWithOptions(map[string]string{"OLD": "v"}, &cfg, opts); err != nil {
+		t.Fatalf("UnmarshalWithOptions() error = %v", err)
+	}
+	if len(got) != 1 || got[0] != "OLD: use NEW" {
+		t.Errorf("warnings = %q", got)
+	}
+}
+
+func TestUnmarshalWithOptionsAutoKeys(t *testing.T) {
+	type Config struct {
+		MaxConns int
+	}
+
+	var cfg Config
+	if err := UnmarshalWithOptions(map[string]string{"APP_MAX_CONNS": "3"}, &cfg, Options{Prefix: "APP_"}); err != nil || cfg.MaxConns != 0 {
+		t.Errorf("without AutoKeys = %+v, %v", cfg, err)
+	}
+	if err := UnmarshalWithOptions(map[string]string{"APP_MAX_CONNS": "3"}, &cfg, Options{Prefix: "APP_", AutoKeys: true}); err != nil || cfg.MaxConns != 3 {
+		t.Errorf("with AutoKeys = %+v, %v", cfg, err)
+	}
+}
+
+func TestProcess(t *testing.T) {
+	t.Setenv("MYAPP_PORT", "8080")
+	t.Setenv("MYAPP_DB_HOST", "db")
+	t.Setenv("PORT", "1")
+
+	type Config struct {
+		Port int `env:"PORT"`
+		DB   struct {
+			Host string `env:"HOST"`
+		} `envPrefix:"DB_"`
+	}
+
+	var cfg Config
+	if err := Process("myapp", &cfg); err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+	if cfg.Port != 8080 || cfg.DB.Host != "db" {
+		t.Errorf("Process() = %+v", cfg)
+	}
+
+	cfg = Config{}
+	if err := Process("", &cfg); err != nil || cfg.Port != 1 {
+		t.Errorf("Process(\"\") = %+v, %v", cfg, err)
+	}
+}
+
+func TestUnmarshalWithOptionsDisallowUnknownKeys(t *testing.T) {
+	type Config struct {
+		Host     string `env:"DB_HOST"`
+		Password string `env:"DB_PASSWORD"`
+	}
+
+	envs := map[string]string{
+		"APP_DB_HOST":    "db",
+		"APP_DB_PASWORD": "secret",
+		"APP_DEBUG":      "true",
+		"PATH":           "/usr/bin",
+	}
+	opts := Options{Prefix: "APP_", DisallowUnknownKeys: true}
+
+	var cfg Config
+	err := UnmarshalWithOptions(envs, &cfg, opts)
+	if !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("UnmarshalWithOptions() error = %v, want ErrUnknownKey", err)
+	}
+	msg := err.Error()
+	if !strings.Contains(msg, "APP_DB_PASWORD") || !strings.Contains(msg, "APP_DEBUG") || strings.Contains(msg, "PATH") {
+		t.Errorf("UnmarshalWithOptions() error = %v", err)
+	}
+
+	if err := UnmarshalWithOptions(map[string]string{"APP_DB_HOST": "db", "PATH": "/usr/bin"}, &cfg, opts); err != nil {
+		t.Errorf("UnmarshalWithOptions() error = %v", err)
+	}
+
+	// A key whose value fails to parse is reported once, not as unknown.
+	type Server struct {
+		Port int `env:"PORT"`
+	}
+	var srv Server
+	err = UnmarshalWithOptions(map[string]string{"APP_PORT": "abc"}, &srv, opts)
+	if err == nil || errors.Is(err, ErrUnknownKey) || len(FieldErrors(err)) != 1 {
+		t.Errorf("UnmarshalWithOptions() error = %v, want only the PORT parse error", err)
+	}
+}
+
+func TestUnmarshalWithOptionsFailFast(t *testing.T) {
+	type Config struct {
+		A int `env:"A"`
+		B int `env:"B,required"`
+		C int `env:"C"`
+		D int `env:"D,exclusive_with=C"`
+	}
+
+	envs := map[string]string{"A": "x", "C": "y", "D": "1"}
+
+	var cfg Config
+	err := UnmarshalWithOptions(envs, &cfg, Options{FailFast: true})
+	if fields := FieldErrors(err); len(fields) != 1 || fields[0].Key != "A" {
+		t.Errorf("FailFast error = %v, want only A", err)
+	}
+
+	first := UnmarshalWithOptions(envs, &cfg, Options{})
+	var agg Errors
+	if !errors.As(first, &agg) || len(agg) != 4 {
+		t.Fatalf("collect-all error = %v, want 4 errors", first)
+	}
+	for range 20 {
+		if err := UnmarshalWithOptions(envs, &cfg, Options{}); err.Error() != first.Error() {
+			t.Fatalf("error order changed:\n%v\nvs\n%v", err, first)
+		}
+	}
+}
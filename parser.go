@@ -1,21 +1,94 @@
 package envParser
 
 import (
+	"cmp"
+	"encoding"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"math"
+	"net"
+	"net/url"
 	"os"
 	"reflect"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
+	"unicode"
 )
 
 var (
 	// Tag is the struct tag key used to identify environment variable names.
 	Tag = "env"
+	// NestedTag is the struct tag on a nested struct field that overrides the
+	// tag key used for that struct's fields, e.g. `envTag:"cfg"`.
+	NestedTag = "envTag"
+	// PrefixTag is the struct tag on a nested struct field that prepends a
+	// prefix to the keys of that struct's fields, e.g. `envPrefix:"DB_"`.
+	PrefixTag = "envPrefix"
 	// Separator is the default separator used for slice and map values.
 	Separator = ";"
+	// KeyValueSeparator is the default separator between keys and values of
+	// map entries.
+	KeyValueSeparator = ":"
+	// LenientBools additionally accepts yes/no, y/n, on/off and
+	// enabled/disabled, in any case, for bool fields. Disabled by default.
+	LenientBools = false
+	// TrimElements trims surrounding whitespace from slice elements and map
+	// keys and values, as the trim tag option does. Disabled by default.
+	TrimElements = false
+	// Strict enables additional tag checks, such as rejecting collection-only
+	// options (separator, lowerkeys) on scalar fields. Disabled by default.
+	Strict = false
+	// KeyNormalizeReplacer, when set, is applied to both environment keys and
+	// tag keys before matching, e.g. strings.NewReplacer(".", "_", "-", "_")
+	// lets DB.HOST and DB-HOST populate a field tagged DB_HOST.
+	KeyNormalizeReplacer *strings.Replacer
+	// AutoKeys derives the key of untagged fields from the field name in
+	// SCREAMING_SNAKE_CASE (MaxConns becomes MAX_CONNS) and prefixes the keys
+	// of untagged nested structs with their field name, unless they have a
+	// PrefixTag. Disabled by default.
+	AutoKeys = false
+	// DisallowUnknownKeys makes Unmarshal fail for every key that no field
+	// consumed, catching typos such as DB_PASWORD. With a prefix only keys
+	// under the prefix are checked; otherwise use it with loaders that
+	// ignore the system environment. Disabled by default.
+	DisallowUnknownKeys = false
+	// ConsumeKeys makes Unmarshal delete the keys it consumed from the map it
+	// was given, leaving only unused keys, as earlier versions always did.
+	// Disabled by default, so the input map is not modified.
+	ConsumeKeys = false
+	// FailFast makes Unmarshal stop at the first failing field instead of
+	// reporting every failure. Disabled by default.
+	FailFast = false
+	// SecretsDir makes Unmarshal read every file in the directory as a
+	// variable named after the file, as described in ReadSecretsDir, e.g.
+	// DefaultSecretsDir for Docker secrets. Variables that are already set
+	// take precedence. Empty, the default, disables it.
+	SecretsDir = ""
+	// Duplicates controls which value is kept when a key appears more than
+	// once, e.g. in both the system environment and a .env file.
+	Duplicates = LastWins
+)
+
+// DuplicateMode selects how repeated keys are resolved.
+type DuplicateMode int
+
+const (
+	// LastWins keeps the last occurrence of a key. With UnmarshalFromFile this
+	// means file values override system environment variables.
+	LastWins DuplicateMode = iota
+	// FirstWins keeps the first occurrence of a key. With UnmarshalFromFile this
+	// means system environment variables override file values.
+	FirstWins
 )
 
 // Validator is an interface for validating structs after unmarshaling.
@@ -44,16 +117,174 @@ func getValidator() Validator {
 	return validator
 }
 
+var (
+	unknownKeyHook   func(key, value string)
+	unknownKeyHookMu sync.RWMutex
+)
+
+// SetUnknownKeyHook sets a function to be called for every environment key
+// left unconsumed after a successful unmarshal, in sorted key order.
+// Pass nil to remove the hook.
+// This function is thread-safe.
+func SetUnknownKeyHook(hook func(key, value string)) {
+	unknownKeyHookMu.Lock()
+	defer unknownKeyHookMu.Unlock()
+	unknownKeyHook = hook
+}
+
+func getUnknownKeyHook() func(key, value string) {
+	unknownKeyHookMu.RLock()
+	defer unknownKeyHookMu.RUnlock()
+	return unknownKeyHook
+}
+
+var (
+	warningHook   func(key, message string)
+	warningHookMu sync.RWMutex
+)
+
+// SetWarningHook sets a function to be called with a warning for every
+// environment key that is used but tagged deprecated, along with the
+// deprecation message. The field is still populated.
+// Pass nil to remove the hook.
+// This function is thread-safe.
+func SetWarningHook(hook func(key, message string)) {
+	warningHookMu.Lock()
+	defer warningHookMu.Unlock()
+	warningHook = hook
+}
+
+func getWarningHook() func(key, message string) {
+	warningHookMu.RLock()
+	defer warningHookMu.RUnlock()
+	return warningHook
+}
+
 type tagField struct {
-	Key       string
-	Default   string
-	Required  bool
-	Separator string
+	Key            string
+	Aliases        []string
+	Default        string
+	Required       bool
+	NotEmpty       bool
+	Unset          bool
+	Expand         bool
+	File           bool
+	Separator      string
+	KVSep          string
+	InnerSep       string
+	LowerKeys      bool
+	Trim           bool
+	OmitEmpty      bool
+	Lenient        bool
+	Unit           string
+	Layout         string
+	Schemes        []string
+	Encoding       string
+	Example        string
+	Doc            string
+	Deprecated     string
+	OneOf          []string
+	Exclusive      []string
+	OneOfKeys      []string
+	Pattern        string
+	Min            string
+	Max            string
+	RequiredIf     *condition
+	RequiredUnless *condition
+	Unique         bool
+	Struct         bool
+	JSON           bool
+	Presence       bool
+	Secret         bool
+	// Extra holds the name=value options the parser does not recognize,
+	// by lower-cased name, for extensions.
+	Extra map[string]string
+}
+
+var durationUnits = map[string]time.Duration{
+	"ns": time.Nanosecond,
+	"us": time.Microsecond,
+	"µs": time.Microsecond,
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+}
+
+// unitBytes is the unit option value for human-readable byte sizes.
+const unitBytes = "bytes"
+
+// byteUnits maps byte size suffixes to multipliers. Suffixes are matched
+// case-insensitively; K, M, G... are decimal and Ki, Mi, Gi... are binary,
+// with an optional trailing B.
+var byteUnits = map[string]uint64{
+	"":    1,
+	"b":   1,
+	"k":   1e3,
+	"kb":  1e3,
+	"ki":  1 << 10,
+	"kib": 1 << 10,
+	"m":   1e6,
+	"mb":  1e6,
+	"mi":  1 << 20,
+	"mib": 1 << 20,
+	"g":   1e9,
+	"gb":  1e9,
+	"gi":  1 << 30,
+	"gib": 1 << 30,
+	"t":   1e12,
+	"tb":  1e12,
+	"ti":  1 << 40,
+	"tib": 1 << 40,
+	"p":   1e15,
+	"pb":  1e15,
+	"pi":  1 << 50,
+	"pib": 1 << 50,
+}
+
+// parseByteSize parses a byte size such as "512KB", "10MiB", "1.5G" or a
+// bare number of bytes.
+func parseByteSize(value string) (uint64, error) {
+	value = strings.TrimSpace(value)
+	end := strings.IndexFunc(value, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if end < 0 {
+		end = len(value)
+	}
+
+	number, suffix := value[:end], strings.TrimSpace(value[end:])
+	unit, ok := byteUnits[strings.ToLower(suffix)]
+	if number == "" || !ok {
+		return 0, fmt.Errorf("invalid byte size: %q", value)
+	}
+
+	if n, err := strconv.ParseUint(number, 10, 64); err == nil {
+		if n > math.MaxUint64/unit {
+			return 0, fmt.Errorf("byte size %q overflows uint64", value)
+		}
+		return n * unit, nil
+	}
+
+	n, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid byte size: %q", value)
+	}
+
+	size := n * float64(unit)
+	if size >= math.MaxUint64 {
+		return 0, fmt.Errorf("byte size %q overflows uint64", value)
+	}
+
+	return uint64(size), nil
 }
 
 // EnvironToMap converts a slice of environment variables in "KEY=value" format
-// to a map. Returns ErrInvalidEnviron if any entry is malformed.
+// to a map. Repeated keys are resolved according to Duplicates.
+// Returns ErrInvalidEnviron if any entry is malformed.
 func EnvironToMap(env []string) (map[string]string, error) {
+	mode := Duplicates
+
 	m := make(map[string]string, len(env))
 	for _, s := range env {
 		parts := strings.SplitN(s, "=", 2)
@@ -61,12 +292,29 @@ func EnvironToMap(env []string) (map[string]string, error) {
 			return nil, ErrInvalidEnviron
 		}
 
+		if _, exists := m[parts[0]]; exists && mode == FirstWins {
+			continue
+		}
+
 		m[parts[0]] = parts[1]
 	}
 
 	return m, nil
 }
 
+// RequireKeys checks that every key in keys is present in envs, independent of
+// any struct. The returned error aggregates one error per missing key.
+func RequireKeys(envs map[string]string, keys []string) error {
+	var err error
+	for _, key := range keys {
+		if _, ok := envs[key]; !ok {
+			err = errors.Join(err, &FieldError{Key: key, Err: ErrRequired})
+		}
+	}
+
+	return err
+}
+
 // UnmarshalFromEnv unmarshals environment variables from os.Environ() into v.
 // v must be a non-nil pointer to a struct.
 func UnmarshalFromEnv(v interface{}) error {
@@ -78,9 +326,30 @@ func UnmarshalFromEnv(v interface{}) error {
 	return Unmarshal(envs, v)
 }
 
+// Process unmarshals the system environment into v with an application
+// prefix on every key, in the style of envconfig: Process("myapp", &cfg)
+// populates a field tagged PORT from MYAPP_PORT and ignores other variables.
+// The prefix is upper-cased and joined with an underscore; an empty prefix
+// behaves like UnmarshalFromEnv.
+// v must be a non-nil pointer to a struct.
+func Process(prefix string, v interface{}) error {
+	envs, err := EnvironToMap(os.Environ())
+	if err != nil {
+		return err
+	}
+
+	opts := DefaultOptions()
+	if prefix != "" {
+		opts.Prefix = strings.ToUpper(prefix) + "_"
+	}
+
+	return newDecoder(opts).decode(envs, v)
+}
+
 // UnmarshalFromFile reads a .env file and unmarshals its contents into v,
 // merged with the current system environment variables.
-// File values take precedence over system environment variables.
+// By default file values take precedence over system environment variables;
+// set Duplicates to FirstWins to reverse this.
 // v must be a non-nil pointer to a struct.
 func UnmarshalFromFile(path string, v interface{}) error {
 	data, err := os.ReadFile(path)
@@ -88,15 +357,28 @@ func UnmarshalFromFile(path string, v interface{}) error {
 		return err
 	}
 
-	fileEnvs := parseEnvFile(string(data))
-	fullEnvs := append(os.Environ(), fileEnvs...)
+	return unmarshalEnvFile(path, string(data), os.Environ(), v)
+}
 
-	envs, err := EnvironToMap(fullEnvs)
+// UnmarshalFromFileProfile is like UnmarshalFromFile but also applies the
+// entries of the [profile] section of the file, such as [production], over
+// its unsectioned common block. Entries of other sections are ignored.
+// v must be a non-nil pointer to a struct.
+func UnmarshalFromFileProfile(path, profile string, v interface{}) error {
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return err
 	}
 
-	return Unmarshal(envs, v)
+	p := newEnvFileParser(osIncludes)
+	p.profile = profile
+
+	fileEnvs, err := p.parse(path, string(data))
+	if err != nil {
+		return err
+	}
+
+	return unmarshalEntries(append(os.Environ(), fileEnvs...), v)
 }
 
 // UnmarshalFromFileOnly reads a .env file and unmarshals its contents into v.
@@ -108,54 +390,260 @@ func UnmarshalFromFileOnly(path string, v interface{}) error {
 		return err
 	}
 
-	fileEnvs := parseEnvFile(string(data))
+	return unmarshalEnvFile(path, string(data), nil, v)
+}
 
-	envs, err := EnvironToMap(fileEnvs)
+// UnmarshalFromReader reads .env formatted content from r and unmarshals it
+// into v. Like UnmarshalFromFileOnly, system environment variables are ignored.
+// v must be a non-nil pointer to a struct.
+func UnmarshalFromReader(r io.Reader, v interface{}) error {
+	data, err := io.ReadAll(r)
 	if err != nil {
 		return err
 	}
 
-	return Unmarshal(envs, v)
+	return unmarshalEnvFile("", string(data), nil, v)
 }
 
-func parseEnvFile(content string) []string {
-	lines := strings.Split(content, "\n")
-	result := make([]string, 0, len(lines))
+// UnmarshalFromFS reads the .env file at path in fsys, such as an embed.FS,
+// and unmarshals its contents into v. Like UnmarshalFromFileOnly, system
+// environment variables are ignored.
+// v must be a non-nil pointer to a struct.
+func UnmarshalFromFS(fsys fs.FS, path string, v interface{}) error {
+	data, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return err
+	}
 
-	for _, line := range lines {
-		line = strings.TrimRight(line, "\r")
-		line = strings.TrimSpace(line)
+	// Includes are resolved within fsys rather than the OS filesystem.
+	fileEnvs, err := newEnvFileParser(fsIncludes(fsys)).parse(path, string(data))
+	if err != nil {
+		return err
+	}
 
-		if line == "" {
-			continue
+	return unmarshalEntries(fileEnvs, v)
+}
+
+// UnmarshalFromFiles reads the .env files in paths, such as .env, .env.local
+// and .env.production, and unmarshals them into v, merged with the current
+// system environment variables. Sources are layered in order, system
+// environment first, so by default later files override earlier ones; set
+// Duplicates to FirstWins to reverse this.
+// v must be a non-nil pointer to a struct.
+func UnmarshalFromFiles(paths []string, v interface{}) error {
+	return unmarshalEnvFiles(paths, os.Environ(), v)
+}
+
+// UnmarshalFromFilesOnly is like UnmarshalFromFiles but ignores system
+// environment variables.
+// v must be a non-nil pointer to a struct.
+func UnmarshalFromFilesOnly(paths []string, v interface{}) error {
+	return unmarshalEnvFiles(paths, nil, v)
+}
+
+// ReadEnvFiles reads the .env files in paths and returns their variables,
+// layered in order like UnmarshalFromFilesOnly, without unmarshaling them.
+func ReadEnvFiles(paths ...string) (map[string]string, error) {
+	entries, err := readEnvFiles(paths, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return EnvironToMap(entries)
+}
+
+// unmarshalEnvFiles reads and parses the .env files in paths, appends their
+// entries to base in order and unmarshals the result into v.
+func unmarshalEnvFiles(paths []string, base []string, v interface{}) error {
+	entries, err := readEnvFiles(paths, base, nil)
+	if err != nil {
+		return err
+	}
+
+	return unmarshalEntries(entries, v)
+}
+
+// readEnvFiles reads and parses the .env files in paths and appends their
+// entries to base in order. Parse errors of all files are reported together.
+// If origins is non-nil, the path of the file whose value wins for each key
+// is recorded in it.
+func readEnvFiles(paths []string, base []string, origins map[string]string) ([]string, error) {
+	var err error
+	for _, path := range paths {
+		data, readErr := os.ReadFile(path)
+		if readErr != nil {
+			return nil, readErr
 		}
 
-		if strings.HasPrefix(line, "#") {
+		fileEnvs, parseErr := parseEnvFile(path, string(data))
+		if parseErr != nil {
+			err = errors.Join(err, parseErr)
 			continue
 		}
 
-		result = append(result, line)
+		base = append(base, fileEnvs...)
+		if origins != nil {
+			for _, entry := range fileEnvs {
+				key, _, _ := strings.Cut(entry, "=")
+				if _, seen := origins[key]; !seen || Duplicates != FirstWins {
+					origins[key] = path
+				}
+			}
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return base, nil
+}
+
+// unmarshalEnvFile parses content as the .env file at path, appends its
+// entries to base and unmarshals the result into v.
+func unmarshalEnvFile(path, content string, base []string, v interface{}) error {
+	fileEnvs, err := parseEnvFile(path, content)
+	if err != nil {
+		return err
+	}
+
+	return unmarshalEntries(append(base, fileEnvs...), v)
+}
+
+// unmarshalEntries unmarshals KEY=value entries into v, resolving repeated
+// keys according to Duplicates.
+func unmarshalEntries(entries []string, v interface{}) error {
+	envs, err := EnvironToMap(entries)
+	if err != nil {
+		return err
 	}
 
-	return result
+	return Unmarshal(envs, v)
 }
 
 // Unmarshal parses environment variables from a map into v.
 // v must be a non-nil pointer to a struct.
 // If a validator is set via SetValidator, it will be called after unmarshaling.
+// Use UnmarshalWithOptions to avoid the package-level configuration.
 func Unmarshal(envs map[string]string, v interface{}) error {
-	if err := unmarshal(envs, v); err != nil {
-		return err
+	return newDecoder(DefaultOptions()).decode(envs, v)
+}
+
+// decoder holds the state of a single Unmarshal call.
+type decoder struct {
+	opts      Options
+	metrics   *Metrics
+	keys      map[string]string
+	source    map[string]string
+	exclusive [][2]string
+	groups    [][]string
+
+	// matched holds the keys a field looked up, whether or not their value
+	// parsed, so checkUnknown only reports keys that no field uses.
+	matched map[string]bool
+
+	// origins maps input keys to their source for provenance; keys not in
+	// it come from SourceEnv. provenance is only recorded when non-nil.
+	origins    map[string]string
+	provenance Provenance
+}
+
+func (d *decoder) decode(envs map[string]string, v interface{}) error {
+	if rv := reflect.ValueOf(v); rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return ErrInvalidValue
+	}
+
+	if !d.opts.ConsumeKeys {
+		// Consumed keys are deleted while decoding; work on a copy so the
+		// caller's map can be reused.
+		envs = maps.Clone(envs)
+	}
+	if d.opts.SecretsDir != "" {
+		secrets, err := ReadSecretsDir(d.opts.SecretsDir)
+		if err != nil {
+			return err
+		}
+		if d.opts.ConsumeKeys {
+			// Decode a merged copy so the caller's map never gains the
+			// secrets, then delete the caller's keys that were consumed.
+			caller := envs
+			envs = maps.Clone(envs)
+			defer func() {
+				for key := range caller {
+					if _, ok := envs[key]; !ok {
+						delete(caller, key)
+					}
+				}
+			}()
+		}
+		for key, value := range secrets {
+			if _, exists := envs[key]; !exists {
+				envs[key] = value
+			}
+		}
+	}
+	d.prepare(envs)
+
+	// Errors are reported in field declaration order, followed by the
+	// cross-field checks, so the aggregate is stable between runs.
+	err := d.unmarshal(envs, v, scope{tag: d.opts.Tag, prefix: d.opts.Prefix, auto: d.opts.AutoKeys})
+	if !d.stop(err) {
+		err = errors.Join(err, d.checkExclusive())
+	}
+	if !d.stop(err) {
+		err = errors.Join(err, d.checkGroups())
+	}
+	if !d.stop(err) && d.opts.DisallowUnknownKeys {
+		err = errors.Join(err, d.checkUnknown(envs))
+	}
+
+	if err != nil {
+		return Errors(flattenErrors(err))
+	}
+
+	if hook := d.opts.UnknownKeyHook; hook != nil {
+		for _, key := range slices.Sorted(maps.Keys(envs)) {
+			hook(key, envs[key])
+		}
 	}
 
-	if val := getValidator(); val != nil {
-		return val.Struct(v)
+	if val := d.opts.Validator; val != nil {
+		if err := val.Struct(v); err != nil {
+			d.metrics.addError()
+			return err
+		}
 	}
 
 	return nil
 }
 
-func unmarshal(envs map[string]string, v interface{}) error {
+// emptyUsesDefault reports whether an empty value of a field of type t
+// tagged tf is replaced by its default, like an unset one, so KEY= in a
+// .env file doesn't turn into a parse error. An empty string is a valid
+// value, so string fields keep it unless they are tagged notEmpty.
+func emptyUsesDefault(t reflect.Type, tf tagField) bool {
+	return tf.Default != "" && (tf.NotEmpty || derefType(t).Kind() != reflect.String)
+}
+
+// record notes the origin of the field at path for provenance.
+func (d *decoder) record(path, key string, defaulted bool) {
+	if d.provenance == nil {
+		return
+	}
+
+	if defaulted {
+		d.provenance[path] = Origin{Source: SourceDefault}
+		return
+	}
+
+	source, ok := d.origins[key]
+	if !ok {
+		source = SourceEnv
+	}
+	d.provenance[path] = Origin{Key: key, Source: source}
+}
+
+func (d *decoder) unmarshal(envs map[string]string, v interface{}, sc scope) error {
 	rv := reflect.ValueOf(v)
 	if rv.Kind() != reflect.Ptr || rv.IsNil() {
 		return ErrInvalidValue
@@ -170,103 +658,1318 @@ func unmarshal(envs map[string]string, v interface{}) error {
 
 	t := rv.Type()
 	for i := range rv.NumField() {
+		if d.stop(err) {
+			break
+		}
+
 		valueField := rv.Field(i)
-		if valueField.Kind() == reflect.Struct {
+		typeField := t.Field(i)
+		tag := sc.fieldTag(typeField)
+		if tag == "-" {
+			// Explicitly skipped, e.g. runtime-only fields such as mutexes.
+			continue
+		}
+
+		if sc.recurse(typeField, tag) {
 			if !valueField.Addr().CanInterface() {
 				continue
 			}
 
-			if unErr := unmarshal(envs, valueField.Addr().Interface()); unErr != nil {
+			if unErr := d.unmarshal(envs, valueField.Addr().Interface(), sc.nested(typeField)); unErr != nil {
 				err = errors.Join(err, unErr)
 				continue
 			}
 		}
 
-		typeField := t.Field(i)
-		tag := typeField.Tag.Get(Tag)
+		if tag == "" && isStructCollection(typeField.Type) && typeField.Tag.Get(PrefixTag) != "" {
+			if !valueField.CanSet() {
+				continue
+			}
+
+			var cErr error
+			if typeField.Type.Kind() == reflect.Slice {
+				cErr = d.unmarshalStructSlice(envs, valueField, sc.nested(typeField))
+			} else {
+				cErr = d.unmarshalStructMap(envs, valueField, sc.nested(typeField))
+			}
+			if cErr != nil {
+				err = errors.Join(err, cErr)
+			}
+			continue
+		}
+
 		if tag == "" {
 			continue
 		}
 
+		tf := sc.parseTag(tag)
 		if !valueField.CanSet() {
-			err = errors.Join(err, fmt.Errorf("field %s is not exported", typeField.Name))
+			err = errors.Join(err, d.fieldError(t, typeField, sc, tf.Key, "", errors.New("field is not exported")))
 			continue
 		}
 
-		tf := parseTag(tag)
+		d.metrics.addField()
+
+		for _, other := range tf.Exclusive {
+			d.exclusive = append(d.exclusive, [2]string{tf.Key, other})
+		}
+		if len(tf.OneOfKeys) > 0 {
+			d.groups = append(d.groups, append([]string{tf.Key}, tf.OneOfKeys...))
+		}
+
+		if d.opts.Strict {
+			if tagErr := tf.validate(typeField.Type); tagErr != nil {
+				err = errors.Join(err, d.fieldError(t, typeField, sc, tf.Key, "", tagErr))
+				continue
+			}
+		}
+
+		if tf.Separator == "" && !tf.Struct {
+			tf.Separator = d.opts.Separator
+		}
+		if tf.KVSep == "" {
+			tf.KVSep = d.opts.KeyValueSeparator
+		}
+		if d.opts.TrimElements {
+			tf.Trim = true
+		}
+		tf.Lenient = d.opts.LenientBools
+
+		key, envValue, ok := d.lookupField(envs, tf)
+		if !ok && tf.File {
+			// Follow the KEY_FILE convention used by container images.
+			key, envValue, ok = d.lookup(envs, tf.Key+"_FILE")
+		}
+		if ok {
+			d.matched[key] = true
+		}
+		if ok && tf.Deprecated != "" && d.opts.WarningHook != nil {
+			d.opts.WarningHook(key, tf.Deprecated)
+		}
+		if ok && envValue == "" && tf.Presence {
+			envValue = "true"
+		}
 
-		envValue, ok := envs[tf.Key]
+		defaulted := false
 		if !ok {
-			if tf.Required && tf.Default == "" {
-				err = errors.Join(err, fmt.Errorf("required field: %s not found", tf.Key))
+			if d.required(tf) && tf.Default == "" {
+				err = errors.Join(err, d.fieldError(t, typeField, sc, tf.Key, "", ErrRequired))
 				continue
 			}
 
 			if tf.Default != "" {
 				envValue = tf.Default
+				defaulted = true
+				d.metrics.addDefault()
 			} else {
 				continue
 			}
+		} else if envValue == "" && emptyUsesDefault(typeField.Type, tf) {
+			envValue = tf.Default
+			defaulted = true
+			d.metrics.addDefault()
+		} else if envValue == "" && tf.NotEmpty {
+			err = errors.Join(err, d.fieldError(t, typeField, sc, key, envValue, ErrEmpty))
+			continue
+		}
+
+		if tf.Expand {
+			envValue = os.Expand(envValue, func(name string) string {
+				_, v, _ := d.lookup(d.source, name)
+				return v
+			})
+		}
+
+		// Timings include reading file fields, which may be slow.
+		var start time.Time
+		if d.metrics != nil {
+			start = time.Now()
+		}
+
+		if tf.File {
+			data, rErr := os.ReadFile(envValue)
+			if rErr != nil {
+				if d.metrics != nil {
+					d.metrics.addTiming(tf.Key, time.Since(start))
+				}
+				err = errors.Join(err, d.fieldError(t, typeField, sc, key, envValue, fmt.Errorf("cannot read file: %w", rErr)))
+				continue
+			}
+			envValue = strings.TrimSpace(string(data))
+		}
+
+		rawValue := envValue
+
+		var setErr error
+		switch {
+		case tf.Struct:
+			setErr = d.setCompactStruct(valueField, envValue, tf, sc)
+		case tf.JSON:
+			if jsonErr := json.Unmarshal([]byte(envValue), valueField.Addr().Interface()); jsonErr != nil {
+				setErr = fmt.Errorf("invalid JSON: %w", jsonErr)
+			}
+		case typeField.Type.Kind() == reflect.Interface:
+			setErr = d.setImplementation(envs, valueField, envValue, sc)
+		default:
+			setErr = set(typeField.Type, valueField, envValue, tf)
+		}
+
+		if d.metrics != nil {
+			d.metrics.addTiming(tf.Key, time.Since(start))
+		}
+
+		// Secrets are scrubbed even if they fail to parse.
+		if ok && tf.Unset {
+			if unsetErr := os.Unsetenv(key); unsetErr != nil {
+				err = errors.Join(err, unsetErr)
+			}
+		}
+
+		if setErr == nil {
+			setErr = checkOneOf(typeField.Type, envValue, tf)
+		}
+		if setErr == nil {
+			setErr = checkPattern(typeField.Type, envValue, tf)
+		}
+		if setErr == nil {
+			setErr = checkRange(typeField.Type, valueField, tf)
 		}
 
-		if setErr := set(typeField.Type, valueField, envValue, tf.Separator); setErr != nil {
-			err = errors.Join(err, setErr)
+		if setErr != nil {
+			err = errors.Join(err, d.fieldError(t, typeField, sc, key, rawValue, setErr))
 			continue
 		}
 
-		delete(envs, tf.Key)
+		d.record(sc.path+typeField.Name, key, defaulted)
+		delete(envs, key)
 	}
 
 	return err
 }
 
-func parseTag(tag string) tagField {
-	const escapedComma = "\x00"
-	tag = strings.ReplaceAll(tag, `\,`, escapedComma)
+// unmarshalStructSlice decodes a slice of structs from indexed variables:
+// element i is read from the keys under sc.prefix followed by "i_", e.g.
+// SERVERS_0_HOST and SERVERS_1_HOST, stopping at the first missing index.
+// The slice is left untouched if no element is present.
+func (d *decoder) unmarshalStructSlice(envs map[string]string, f reflect.Value, sc scope) error {
+	elemType := f.Type().Elem()
 
-	envKeys := strings.Split(tag, ",")
-	tf := tagField{
-		Key:       envKeys[0],
-		Separator: Separator,
+	var err error
+	dest := reflect.MakeSlice(f.Type(), 0, 0)
+	for i := 0; !d.stop(err); i++ {
+		elemScope := sc.element(strconv.Itoa(i))
+		if !d.hasPrefix(envs, elemScope.prefix) {
+			break
+		}
+
+		elem := reflect.New(derefType(elemType))
+		if elemErr := d.unmarshal(envs, elem.Interface(), elemScope); elemErr != nil {
+			err = errors.Join(err, elemErr)
+		}
+
+		if elemType.Kind() == reflect.Ptr {
+			dest = reflect.Append(dest, elem)
+		} else {
+			dest = reflect.Append(dest, elem.Elem())
+		}
 	}
 
-	for _, key := range envKeys[1:] {
-		keyData := strings.SplitN(key, "=", 2)
-		switch strings.ToLower(keyData[0]) {
-		case "required":
-			tf.Required = true
-			continue
-		case "default":
-			if len(keyData) != 2 {
-				continue
-			}
-			tf.Default = strings.ReplaceAll(keyData[1], escapedComma, ",")
+	if dest.Len() > 0 {
+		f.Set(dest)
+	}
+
+	return err
+}
+
+// unmarshalStructMap decodes a map of structs from variables grouped by name:
+// the element for NAME is read from the keys under sc.prefix followed by
+// "NAME_", e.g. UPSTREAMS_API_URL and UPSTREAMS_WEB_URL. Names are found by
+// matching the keys of the struct's fields as suffixes, the longest first,
+// so they may contain underscores. The map is left untouched if no element is present.
+func (d *decoder) unmarshalStructMap(envs map[string]string, f reflect.Value, sc scope) error {
+	elemType := f.Type().Elem()
+
+	var fieldKeys []string
+	walkFields(derefType(elemType), scope{tag: sc.tag, auto: sc.auto}, func(_ string, _ reflect.StructField, tf tagField) {
+		fieldKeys = append(fieldKeys, "_"+tf.Key)
+	})
+	// Try longer keys first so that with fields URL and API_URL, the key
+	// WEB_API_URL names the element WEB rather than WEB_API.
+	slices.SortStableFunc(fieldKeys, func(a, b string) int { return len(b) - len(a) })
+
+	names := make(map[string]bool)
+	for key := range envs {
+		rest, ok := strings.CutPrefix(key, sc.prefix)
+		if !ok {
 			continue
-		case "separator":
-			if len(keyData) != 2 {
-				continue
+		}
+		for _, fieldKey := range fieldKeys {
+			if name, ok := strings.CutSuffix(rest, fieldKey); ok && name != "" {
+				names[name] = true
+				break
 			}
-			tf.Separator = strings.ReplaceAll(keyData[1], escapedComma, ",")
-		default:
-			continue
 		}
 	}
 
-	return tf
-}
+	if len(names) == 0 {
+		return nil
+	}
+
+	var err error
+	dest := reflect.MakeMapWithSize(f.Type(), len(names))
+	for _, name := range slices.Sorted(maps.Keys(names)) {
+		if d.stop(err) {
+			break
+		}
+
+		elem := reflect.New(derefType(elemType))
+		elemScope := sc.element(name)
+		if elemErr := d.unmarshal(envs, elem.Interface(), elemScope); elemErr != nil {
+			err = errors.Join(err, elemErr)
+		}
+
+		if elemType.Kind() != reflect.Ptr {
+			elem = elem.Elem()
+		}
+		dest.SetMapIndex(reflect.ValueOf(name).Convert(f.Type().Key()), elem)
+	}
+
+	f.Set(dest)
+	return err
+}
+
+// hasPrefix reports whether any key in envs starts with prefix, comparing
+// normalized keys when a KeyNormalizeReplacer is set.
+func (d *decoder) hasPrefix(envs map[string]string, prefix string) bool {
+	replacer := d.opts.KeyNormalizeReplacer
+	if replacer != nil {
+		prefix = replacer.Replace(prefix)
+	}
+
+	for key := range envs {
+		if replacer != nil {
+			key = replacer.Replace(key)
+		}
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// setCompactStruct decodes a compact "key=value,key=value" string into the
+// nested struct f, matching keys against the tags of its fields. Pairs are
+// separated by "," unless the field sets its own separator.
+func (d *decoder) setCompactStruct(f reflect.Value, value string, tf tagField, sc scope) error {
+	if f.Kind() != reflect.Struct {
+		return fmt.Errorf("%w: struct option on %s", ErrUnsupportedType, f.Type())
+	}
+
+	sep := ","
+	if tf.Separator != "" {
+		sep = tf.Separator
+	}
+
+	pairs := make(map[string]string)
+	if value != "" {
+		for _, pair := range strings.Split(value, sep) {
+			kv := strings.SplitN(pair, "=", 2)
+			if len(kv) != 2 {
+				return fmt.Errorf("invalid struct entry for %s: %s", tf.Key, pair)
+			}
+			pairs[strings.TrimSpace(kv[0])] = kv[1]
+		}
+	}
+
+	// Keys inside the value are relative to the struct, so no prefix applies.
+	// They are matched separately from the environment's keys.
+	outer := d.matched
+	d.matched = make(map[string]bool)
+	err := d.unmarshal(pairs, f.Addr().Interface(), scope{tag: sc.tag, auto: sc.auto})
+	matched := d.matched
+	d.matched = outer
+
+	for _, key := range slices.Sorted(maps.Keys(pairs)) {
+		if !matched[key] {
+			err = errors.Join(err, fmt.Errorf("unknown key %s in %s", key, tf.Key))
+		}
+	}
+
+	return err
+}
+
+// prepare records envs as the source of cross-field lookups and indexes its
+// normalized keys.
+func (d *decoder) prepare(envs map[string]string) {
+	d.source = maps.Clone(envs)
+
+	if replacer := d.opts.KeyNormalizeReplacer; replacer != nil {
+		d.keys = make(map[string]string, len(envs))
+		for k := range envs {
+			d.keys[replacer.Replace(k)] = k
+		}
+	}
+}
+
+// stop reports whether decoding should stop because err is set and the
+// decoder is in fail-fast mode.
+func (d *decoder) stop(err error) bool {
+	return err != nil && d.opts.FailFast
+}
+
+// fieldError wraps err, the failure to decode the field sf of the struct
+// type owner from key, in a FieldError and counts it. Errors that already
+// describe fields of a nested struct were counted when they were created
+// and are returned as is.
+func (d *decoder) fieldError(owner reflect.Type, sf reflect.StructField, sc scope, key, value string, err error) error {
+	var fe *FieldError
+	if errors.As(err, &fe) {
+		return err
+	}
+
+	d.metrics.addError()
+	return &FieldError{Struct: owner.Name(), Field: sc.path + sf.Name, Key: key, Value: value, Err: err}
+}
+
+// checkUnknown reports every key left in envs under the prefix that no
+// field matched.
+func (d *decoder) checkUnknown(envs map[string]string) error {
+	var err error
+	for _, key := range slices.Sorted(maps.Keys(envs)) {
+		if strings.HasPrefix(key, d.opts.Prefix) && !d.matched[key] {
+			d.metrics.addError()
+			err = errors.Join(err, fmt.Errorf("%w: %s", ErrUnknownKey, key))
+		}
+	}
+
+	return err
+}
+
+// checkGroups reports every exactly_one_of group in which no key, or more
+// than one key, is set.
+func (d *decoder) checkGroups() error {
+	var err error
+
+	seen := make(map[string]bool, len(d.groups))
+	for _, group := range d.groups {
+		group = slices.Compact(slices.Sorted(slices.Values(group)))
+		id := strings.Join(group, "\x00")
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		var set []string
+		for _, key := range group {
+			if _, _, ok := d.lookup(d.source, key); ok {
+				set = append(set, key)
+			}
+		}
+
+		switch {
+		case len(set) == 0:
+			d.metrics.addError()
+			err = errors.Join(err, fmt.Errorf("exactly one of %s must be set", strings.Join(group, ", ")))
+		case len(set) > 1:
+			d.metrics.addError()
+			err = errors.Join(err, fmt.Errorf("exactly one of %s must be set, got %s", strings.Join(group, ", "), strings.Join(set, ", ")))
+		}
+	}
+
+	return err
+}
+
+// required reports whether the field described by tf must be set, taking
+// the required_if and required_unless conditions into account.
+func (d *decoder) required(tf tagField) bool {
+	if tf.Required || tf.NotEmpty || d.opts.RequiredIfNoDefault {
+		return true
+	}
+	if tf.RequiredIf != nil && d.conditionMet(tf.RequiredIf) {
+		return true
+	}
+
+	return tf.RequiredUnless != nil && !d.conditionMet(tf.RequiredUnless)
+}
+
+// conditionMet reports whether c holds for the variables being decoded.
+func (d *decoder) conditionMet(c *condition) bool {
+	_, value, ok := d.lookup(d.source, c.Key)
+	return ok && (len(c.Values) == 0 || slices.Contains(c.Values, value))
+}
+
+// checkExclusive reports every pair of mutually exclusive keys that are both
+// present in the source environment. Defaults do not count as present.
+func (d *decoder) checkExclusive() error {
+	var err error
+
+	seen := make(map[[2]string]bool, len(d.exclusive))
+	for _, pair := range d.exclusive {
+		if pair[1] < pair[0] {
+			pair[0], pair[1] = pair[1], pair[0]
+		}
+		if seen[pair] {
+			continue
+		}
+		seen[pair] = true
+
+		_, _, okA := d.lookup(d.source, pair[0])
+		_, _, okB := d.lookup(d.source, pair[1])
+		if okA && okB {
+			d.metrics.addError()
+			err = errors.Join(err, fmt.Errorf("fields %s and %s are mutually exclusive", pair[0], pair[1]))
+		}
+	}
+
+	return err
+}
+
+// lookupField looks up the key of tf, then its aliases in order, and returns
+// the first one present.
+func (d *decoder) lookupField(envs map[string]string, tf tagField) (string, string, bool) {
+	key, value, ok := d.lookup(envs, tf.Key)
+	for _, alias := range tf.Aliases {
+		if ok {
+			break
+		}
+		key, value, ok = d.lookup(envs, alias)
+	}
+
+	if !ok {
+		return tf.Key, "", false
+	}
+
+	return key, value, true
+}
+
+// lookup returns the environment key matching key along with its value.
+// An exact match wins; otherwise keys are compared after normalization.
+func (d *decoder) lookup(envs map[string]string, key string) (string, string, bool) {
+	if v, ok := envs[key]; ok || d.keys == nil {
+		return key, v, ok
+	}
+
+	envKey, ok := d.keys[d.opts.KeyNormalizeReplacer.Replace(key)]
+	if !ok {
+		return key, "", false
+	}
+
+	v, ok := envs[envKey]
+	return envKey, v, ok
+}
+
+// scope is the tag key and key prefix in effect for the struct being decoded.
+// With auto set, untagged fields use keys derived from their names. path is
+// the dotted field path of the struct, used in errors.
+type scope struct {
+	tag    string
+	prefix string
+	path   string
+	auto   bool
+}
+
+// nested returns the scope for the fields of the nested struct sf, applying
+// its NestedTag override and appending its PrefixTag prefix. With auto keys,
+// a named nested struct without a PrefixTag is prefixed with its field name.
+func (sc scope) nested(sf reflect.StructField) scope {
+	if override := sf.Tag.Get(NestedTag); override != "" {
+		sc.tag = override
+	}
+
+	prefix, ok := sf.Tag.Lookup(PrefixTag)
+	if !ok && sc.auto && !sf.Anonymous {
+		prefix = screamingSnake(sf.Name) + "_"
+	}
+
+	sc.prefix += prefix
+	sc.path += sf.Name + "."
+	return sc
+}
+
+// element returns the scope for the struct collection element name.
+func (sc scope) element(name string) scope {
+	sc.prefix += name + "_"
+	sc.path += name + "."
+	return sc
+}
+
+// fieldTag returns the tag of sf. With auto keys, untagged exported fields
+// other than nested structs and struct collections get their name in
+// SCREAMING_SNAKE_CASE, e.g. MaxConns becomes MAX_CONNS.
+func (sc scope) fieldTag(sf reflect.StructField) string {
+	tag := sf.Tag.Get(sc.tag)
+	if tag != "" || !sc.auto || !sf.IsExported() {
+		return tag
+	}
+
+	t := derefType(sf.Type)
+	if (t.Kind() == reflect.Struct && !isLeafType(t)) || isStructCollection(sf.Type) {
+		return ""
+	}
+
+	return screamingSnake(sf.Name)
+}
+
+// recurse reports whether the fields of the struct field sf with tag are
+// decoded as a nested struct.
+func (sc scope) recurse(sf reflect.StructField, tag string) bool {
+	if sf.Type.Kind() != reflect.Struct || !parseTag(tag).recurse() {
+		return false
+	}
+
+	return !sc.auto || !isLeafType(sf.Type)
+}
+
+// isLeafType reports whether the struct type t is decoded from a single
+// value rather than field by field.
+func isLeafType(t reflect.Type) bool {
+	if _, ok := getParser(t); ok {
+		return true
+	}
+
+	return t == timeType || t == urlType || t == ipNetType || reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
+
+// screamingSnake converts a Go identifier to SCREAMING_SNAKE_CASE, keeping
+// acronyms together: DBHost becomes DB_HOST and APIKey becomes API_KEY.
+func screamingSnake(name string) string {
+	runes := []rune(name)
+
+	var sb strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				sb.WriteByte('_')
+			}
+		}
+		sb.WriteRune(unicode.ToUpper(r))
+	}
+
+	return sb.String()
+}
+
+// parseTag parses tag and prefixes the keys it references.
+func (sc scope) parseTag(tag string) tagField {
+	tf := parseTag(tag)
+	if sc.prefix == "" {
+		return tf
+	}
+
+	tf.Key = sc.prefix + tf.Key
+	for i, alias := range tf.Aliases {
+		tf.Aliases[i] = sc.prefix + alias
+	}
+	for i, other := range tf.Exclusive {
+		tf.Exclusive[i] = sc.prefix + other
+	}
+	for i, other := range tf.OneOfKeys {
+		tf.OneOfKeys[i] = sc.prefix + other
+	}
+	if tf.RequiredIf != nil {
+		tf.RequiredIf.Key = sc.prefix + tf.RequiredIf.Key
+	}
+	if tf.RequiredUnless != nil {
+		tf.RequiredUnless.Key = sc.prefix + tf.RequiredUnless.Key
+	}
+
+	return tf
+}
+
+// condition is a required_if or required_unless rule. It holds when Key is
+// set and, if Values is not empty, equal to one of them.
+type condition struct {
+	Key    string
+	Values []string
+}
+
+// parseCondition parses "KEY" or "KEY=A|B".
+func parseCondition(s string) *condition {
+	key, values, ok := strings.Cut(s, "=")
+	c := &condition{Key: key}
+	if ok {
+		c.Values = strings.Split(values, "|")
+	}
+
+	return c
+}
+
+func parseTag(tag string) tagField {
+	const escapedComma = "\x00"
+	tag = strings.ReplaceAll(tag, `\,`, escapedComma)
+
+	envKeys := strings.Split(tag, ",")
+	names := strings.Split(envKeys[0], "|")
+	tf := tagField{
+		Key:     names[0],
+		Aliases: names[1:],
+	}
+	if len(tf.Aliases) == 0 {
+		tf.Aliases = nil
+	}
+
+	for _, key := range envKeys[1:] {
+		keyData := strings.SplitN(key, "=", 2)
+		switch strings.ToLower(keyData[0]) {
+		case "required":
+			tf.Required = true
+			continue
+		case "notempty":
+			tf.NotEmpty = true
+			continue
+		case "unset":
+			tf.Unset = true
+			continue
+		case "expand":
+			tf.Expand = true
+			continue
+		case "file":
+			tf.File = true
+			continue
+		case "lowerkeys":
+			tf.LowerKeys = true
+			continue
+		case "trim":
+			tf.Trim = true
+			continue
+		case "omitempty":
+			tf.OmitEmpty = true
+			continue
+		case "unique":
+			tf.Unique = true
+			continue
+		case "struct":
+			tf.Struct = true
+			continue
+		case "json":
+			tf.JSON = true
+			continue
+		case "presence":
+			tf.Presence = true
+			continue
+		case "secret":
+			tf.Secret = true
+			continue
+		case "default":
+			if len(keyData) != 2 {
+				continue
+			}
+			tf.Default = strings.ReplaceAll(keyData[1], escapedComma, ",")
+			continue
+		case "separator":
+			if len(keyData) != 2 {
+				continue
+			}
+			tf.Separator = strings.ReplaceAll(keyData[1], escapedComma, ",")
+		case "kvsep":
+			if len(keyData) != 2 {
+				continue
+			}
+			tf.KVSep = strings.ReplaceAll(keyData[1], escapedComma, ",")
+		case "innersep":
+			if len(keyData) != 2 {
+				continue
+			}
+			tf.InnerSep = strings.ReplaceAll(keyData[1], escapedComma, ",")
+		case "unit":
+			if len(keyData) != 2 {
+				continue
+			}
+			tf.Unit = strings.ToLower(keyData[1])
+		case "layout":
+			if len(keyData) != 2 {
+				continue
+			}
+			tf.Layout = strings.ReplaceAll(keyData[1], escapedComma, ",")
+		case "example":
+			if len(keyData) != 2 {
+				continue
+			}
+			tf.Example = strings.ReplaceAll(keyData[1], escapedComma, ",")
+		case "deprecated":
+			if len(keyData) != 2 {
+				continue
+			}
+			tf.Deprecated = strings.ReplaceAll(keyData[1], escapedComma, ",")
+		case "doc", "desc":
+			if len(keyData) != 2 {
+				continue
+			}
+			tf.Doc = strings.ReplaceAll(keyData[1], escapedComma, ",")
+		case "exclusive_with":
+			if len(keyData) != 2 {
+				continue
+			}
+			tf.Exclusive = strings.Split(keyData[1], "|")
+		case "pattern":
+			if len(keyData) != 2 {
+				continue
+			}
+			tf.Pattern = strings.ReplaceAll(keyData[1], escapedComma, ",")
+		case "min":
+			if len(keyData) != 2 {
+				continue
+			}
+			tf.Min = keyData[1]
+		case "max":
+			if len(keyData) != 2 {
+				continue
+			}
+			tf.Max = keyData[1]
+		case "exactly_one_of":
+			if len(keyData) != 2 {
+				continue
+			}
+			tf.OneOfKeys = strings.Split(keyData[1], "|")
+		case "required_if":
+			if len(keyData) != 2 {
+				continue
+			}
+			tf.RequiredIf = parseCondition(keyData[1])
+		case "required_unless":
+			if len(keyData) != 2 {
+				continue
+			}
+			tf.RequiredUnless = parseCondition(keyData[1])
+		case "base64":
+			tf.Encoding = "base64"
+			continue
+		case "encoding":
+			if len(keyData) != 2 {
+				continue
+			}
+			tf.Encoding = strings.ToLower(keyData[1])
+		case "schemes":
+			if len(keyData) != 2 {
+				continue
+			}
+			tf.Schemes = strings.Split(strings.ToLower(keyData[1]), "|")
+		case "oneof":
+			if len(keyData) != 2 {
+				continue
+			}
+			tf.OneOf = strings.Split(strings.ReplaceAll(keyData[1], escapedComma, ","), "|")
+		default:
+			if len(keyData) != 2 {
+				continue
+			}
+			if tf.Extra == nil {
+				tf.Extra = make(map[string]string)
+			}
+			tf.Extra[strings.ToLower(keyData[0])] = strings.ReplaceAll(keyData[1], escapedComma, ",")
+		}
+	}
+
+	return tf
+}
+
+// recurse reports whether the fields of a struct field tagged tf are decoded
+// individually. The struct and json options decode the whole struct from its
+// own value instead.
+func (tf tagField) recurse() bool {
+	return !tf.Struct && !tf.JSON
+}
+
+func (tf tagField) separator() string {
+	if tf.Separator == "" {
+		return Separator
+	}
+
+	return tf.Separator
+}
+
+// split splits a collection value into its elements, trimming them if the
+// trim option is set and dropping empty ones if the omitempty option is set.
+func (tf tagField) split(value string) []string {
+	values := strings.Split(value, tf.separator())
+	if tf.Trim {
+		for i, v := range values {
+			values[i] = strings.TrimSpace(v)
+		}
+	}
+
+	if tf.OmitEmpty {
+		values = slices.DeleteFunc(values, func(v string) bool { return v == "" })
+	}
+
+	return values
+}
+
+// inner returns the tag options for the elements of a collection, which are
+// split by InnerSep, e.g. the rows of a [][]int or the slice values of a
+// map[string][]string.
+func (tf tagField) inner() tagField {
+	if tf.InnerSep != "" {
+		tf.Separator = tf.InnerSep
+		tf.InnerSep = ""
+	}
+
+	return tf
+}
+
+func (tf tagField) kvSeparator() string {
+	if tf.KVSep == "" {
+		return KeyValueSeparator
+	}
+
+	return tf.KVSep
+}
+
+// validate reports tag options that have no effect on a field of type t.
+func (tf tagField) validate(t reflect.Type) error {
+	t = derefType(t)
+
+	isCollection := isCollectionType(t)
+	if tf.Separator != "" && !isCollection && !tf.Struct {
+		return fmt.Errorf("%w: separator requires a slice, array or map, got %s", ErrInvalidTagOption, t)
+	}
+
+	if tf.Unique && (t.Kind() != reflect.Slice || !t.Elem().Comparable()) {
+		return fmt.Errorf("%w: unique requires a slice of comparable elements, got %s", ErrInvalidTagOption, t)
+	}
+
+	if tf.Struct && t.Kind() != reflect.Struct {
+		return fmt.Errorf("%w: struct requires a struct, got %s", ErrInvalidTagOption, t)
+	}
+
+	if tf.Presence && t.Kind() != reflect.Bool {
+		return fmt.Errorf("%w: presence requires a bool, got %s", ErrInvalidTagOption, t)
+	}
+
+	if tf.LowerKeys && t.Kind() != reflect.Map {
+		return fmt.Errorf("%w: lowerkeys requires a map, got %s", ErrInvalidTagOption, t)
+	}
+
+	if tf.Trim && !isCollection {
+		return fmt.Errorf("%w: trim requires a slice, array or map, got %s", ErrInvalidTagOption, t)
+	}
+
+	if tf.OmitEmpty && !isCollection {
+		return fmt.Errorf("%w: omitempty requires a slice, array or map, got %s", ErrInvalidTagOption, t)
+	}
+
+	if tf.KVSep != "" && t.Kind() != reflect.Map {
+		return fmt.Errorf("%w: kvsep requires a map, got %s", ErrInvalidTagOption, t)
+	}
+
+	if tf.InnerSep != "" && (!isCollection || !isCollectionType(derefType(t.Elem()))) {
+		return fmt.Errorf("%w: innersep requires a slice or map of slices or maps, got %s", ErrInvalidTagOption, t)
+	}
+
+	if tf.Unit == unitBytes && !isInteger(t) && (!isCollection || !isInteger(derefType(t.Elem()))) {
+		return fmt.Errorf("%w: unit=bytes requires an integer, got %s", ErrInvalidTagOption, t)
+	}
+
+	if tf.Layout != "" && t != timeType && (!isCollection || t.Elem() != timeType) {
+		return fmt.Errorf("%w: layout requires a time.Time, got %s", ErrInvalidTagOption, t)
+	}
+
+	if tf.Encoding != "" && !isEncodable(t) && (!isCollection || !isEncodable(derefType(t.Elem()))) {
+		return fmt.Errorf("%w: encoding requires a []byte or string, got %s", ErrInvalidTagOption, t)
+	}
+
+	if len(tf.Schemes) > 0 && t != urlType && (!isCollection || derefType(t.Elem()) != urlType) {
+		return fmt.Errorf("%w: schemes requires a url.URL, got %s", ErrInvalidTagOption, t)
+	}
+
+	if tf.Pattern != "" {
+		if _, err := compilePattern(tf.Pattern); err != nil {
+			return fmt.Errorf("%w: invalid pattern: %v", ErrInvalidTagOption, err)
+		}
+	}
+
+	if (tf.Min != "" || tf.Max != "") && !isNumber(t) {
+		return fmt.Errorf("%w: min and max require a number or duration, got %s", ErrInvalidTagOption, t)
+	}
+
+	return nil
+}
+
+// uniqueSlice returns s without repeated elements, keeping the first
+// occurrence of each. Slices of non-comparable elements are returned as is.
+func uniqueSlice(s reflect.Value) reflect.Value {
+	if !s.Type().Elem().Comparable() {
+		return s
+	}
+
+	seen := make(map[interface{}]bool, s.Len())
+	dest := reflect.MakeSlice(s.Type(), 0, s.Len())
+	for i := range s.Len() {
+		elem := s.Index(i)
+		if seen[elem.Interface()] {
+			continue
+		}
+
+		seen[elem.Interface()] = true
+		dest = reflect.Append(dest, elem)
+	}
+
+	return dest
+}
+
+var (
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+	timeType            = reflect.TypeFor[time.Time]()
+	urlType             = reflect.TypeFor[url.URL]()
+	ipNetType           = reflect.TypeFor[net.IPNet]()
+)
+
+// derefType returns the type t points to, through any number of pointers.
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t
+}
+
+// isCollectionType reports whether t is a slice, array or map.
+func isCollectionType(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return true
+	default:
+		return false
+	}
+}
+
+// lenientBools maps the extended bool forms accepted with LenientBools.
+var lenientBools = map[string]bool{
+	"yes":      true,
+	"y":        true,
+	"on":       true,
+	"enabled":  true,
+	"no":       false,
+	"n":        false,
+	"off":      false,
+	"disabled": false,
+}
+
+// parseBool parses value with strconv.ParseBool, falling back to the forms
+// in lenientBools when lenient is set.
+func parseBool(value string, lenient bool) (bool, error) {
+	v, err := strconv.ParseBool(value)
+	if err == nil || !lenient {
+		return v, err
+	}
+
+	if v, ok := lenientBools[strings.ToLower(strings.TrimSpace(value))]; ok {
+		return v, nil
+	}
+
+	return false, err
+}
+
+// intBase returns the base to parse the integer value with: 0, letting
+// strconv infer it, for 0x, 0o and 0b prefixed literals and 10 otherwise, so
+// zero-padded decimals like 010 are not read as octal.
+func intBase(value string) int {
+	digits := strings.TrimLeft(value, "+-")
+	if len(digits) > 2 && digits[0] == '0' {
+		switch digits[1] {
+		case 'x', 'X', 'o', 'O', 'b', 'B':
+			return 0
+		}
+	}
+
+	return 10
+}
+
+// isNumber reports whether t is an integer, float or time.Duration type.
+func isNumber(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// checkOneOf checks value against the oneof tag option. The elements of
+// slices and arrays are checked individually.
+func checkOneOf(t reflect.Type, value string, tf tagField) error {
+	if len(tf.OneOf) == 0 {
+		return nil
+	}
+
+	values := []string{value}
+	if t = derefType(t); t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		values = tf.split(value)
+	}
+
+	for _, v := range values {
+		if !slices.Contains(tf.OneOf, v) {
+			return fmt.Errorf("must be one of %s, got %q", strings.Join(tf.OneOf, ", "), v)
+		}
+	}
+
+	return nil
+}
+
+var patterns sync.Map // pattern string -> *regexp.Regexp
+
+// compilePattern compiles pattern once and caches the result.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := patterns.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	patterns.Store(pattern, re)
+
+	return re, nil
+}
+
+// checkPattern matches value against the pattern tag option. The elements
+// of slices and arrays are matched individually.
+func checkPattern(t reflect.Type, value string, tf tagField) error {
+	if tf.Pattern == "" {
+		return nil
+	}
+
+	re, err := compilePattern(tf.Pattern)
+	if err != nil {
+		return fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	values := []string{value}
+	if t = derefType(t); t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		values = tf.split(value)
+	}
+
+	for _, v := range values {
+		if !re.MatchString(v) {
+			return fmt.Errorf("must match %s, got %q", tf.Pattern, v)
+		}
+	}
+
+	return nil
+}
+
+// checkRange checks the number in f against the min and max tag options.
+// Bounds are parsed like the field itself, so durations and byte sizes
+// accept the same units as the value.
+func checkRange(t reflect.Type, f reflect.Value, tf tagField) error {
+	if tf.Min == "" && tf.Max == "" {
+		return nil
+	}
+
+	if t.Kind() == reflect.Ptr {
+		if f.IsNil() {
+			return nil
+		}
+		return checkRange(t.Elem(), f.Elem(), tf)
+	}
+
+	if tf.Min != "" {
+		c, err := compareBound(t, f, tf.Min, tf)
+		if err != nil {
+			return fmt.Errorf("invalid min: %w", err)
+		}
+		if c < 0 {
+			return fmt.Errorf("must be at least %s, got %v", tf.Min, f.Interface())
+		}
+	}
+
+	if tf.Max != "" {
+		c, err := compareBound(t, f, tf.Max, tf)
+		if err != nil {
+			return fmt.Errorf("invalid max: %w", err)
+		}
+		if c > 0 {
+			return fmt.Errorf("must be at most %s, got %v", tf.Max, f.Interface())
+		}
+	}
+
+	return nil
+}
+
+// compareBound parses bound as a value of type t and compares f to it.
+func compareBound(t reflect.Type, f reflect.Value, bound string, tf tagField) (int, error) {
+	b := reflect.New(t).Elem()
+	if err := set(t, b, bound, tf); err != nil {
+		return 0, err
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cmp.Compare(f.Int(), b.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return cmp.Compare(f.Uint(), b.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return cmp.Compare(f.Float(), b.Float()), nil
+	default:
+		return 0, fmt.Errorf("%w: min and max require a number or duration, got %s", ErrUnsupportedType, t)
+	}
+}
+
+// isInteger reports whether t is a signed or unsigned integer type other
+// than time.Duration.
+func isInteger(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return t != reflect.TypeFor[time.Duration]()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	default:
+		return false
+	}
+}
+
+// isStructCollection reports whether t is a slice of structs or a map of
+// structs with string keys, or of pointers to structs.
+func isStructCollection(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Slice:
+		return derefType(t.Elem()).Kind() == reflect.Struct
+	case reflect.Map:
+		return t.Key().Kind() == reflect.String && derefType(t.Elem()).Kind() == reflect.Struct
+	default:
+		return false
+	}
+}
+
+// isBytes reports whether t is a byte slice.
+func isBytes(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+}
+
+// isEncodable reports whether the encoding tag option applies to t.
+func isEncodable(t reflect.Type) bool {
+	return isBytes(t) || t.Kind() == reflect.String
+}
+
+// decodeBytes decodes value with encoding, one of base64, base64url, hex or
+// raw. base64 and base64url accept both padded and unpadded input.
+func decodeBytes(value, encoding string) ([]byte, error) {
+	switch encoding {
+	case "raw":
+		return []byte(value), nil
+	case "hex":
+		return hex.DecodeString(value)
+	case "base64":
+		return base64.StdEncoding.WithPadding(base64.NoPadding).DecodeString(strings.TrimRight(value, "="))
+	case "base64url":
+		return base64.URLEncoding.WithPadding(base64.NoPadding).DecodeString(strings.TrimRight(value, "="))
+	default:
+		return nil, fmt.Errorf("invalid encoding: %s", encoding)
+	}
+}
+
+// parseURL parses value with url.Parse and, if schemes is not empty, checks
+// that the URL uses one of them.
+func parseURL(value string, schemes []string) (*url.URL, error) {
+	u, err := url.Parse(value)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(schemes) > 0 && !slices.Contains(schemes, strings.ToLower(u.Scheme)) {
+		return nil, fmt.Errorf("invalid URL %q: scheme %q not in %s", value, u.Scheme, strings.Join(schemes, "|"))
+	}
+
+	return u, nil
+}
+
+// Layouts accepted by the layout option besides time.Parse layouts.
+const (
+	layoutUnix      = "unix"
+	layoutUnixMilli = "unixmilli"
+)
+
+// parseTime parses value with layout, which defaults to time.RFC3339. The
+// layouts "unix" and "unixmilli" read integer seconds or milliseconds since
+// the Unix epoch.
+func parseTime(value, layout string) (time.Time, error) {
+	switch layout {
+	case "":
+		return time.Parse(time.RFC3339, value)
+	case layoutUnix, layoutUnixMilli:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid %s timestamp %q: %w", layout, value, err)
+		}
+		if layout == layoutUnixMilli {
+			return time.UnixMilli(n), nil
+		}
+		return time.Unix(n, 0), nil
+	default:
+		return time.Parse(layout, value)
+	}
+}
+
+func set(t reflect.Type, f reflect.Value, value string, tf tagField) error {
+	if parser, ok := getParser(t); ok {
+		return setParsed(t, f, value, parser)
+	}
+
+	if t == timeType {
+		v, err := parseTime(value, tf.Layout)
+		if err != nil {
+			return err
+		}
+		f.Set(reflect.ValueOf(v))
+		return nil
+	}
+
+	if t == urlType {
+		u, err := parseURL(value, tf.Schemes)
+		if err != nil {
+			return err
+		}
+		f.Set(reflect.ValueOf(*u))
+		return nil
+	}
+
+	if t == ipNetType {
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return err
+		}
+		f.Set(reflect.ValueOf(*network))
+		return nil
+	}
+
+	if f.CanAddr() && reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return f.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	}
+
+	if tf.Encoding != "" && isBytes(t) {
+		b, err := decodeBytes(value, tf.Encoding)
+		if err != nil {
+			return err
+		}
+		f.SetBytes(b)
+		return nil
+	}
+
+	if tf.Encoding != "" && t.Kind() == reflect.String {
+		b, err := decodeBytes(value, tf.Encoding)
+		if err != nil {
+			return err
+		}
+		f.SetString(string(b))
+		return nil
+	}
 
-func set(t reflect.Type, f reflect.Value, value, sliceSeparator string) error {
 	switch t.Kind() {
 	case reflect.Ptr:
 		ptr := reflect.New(t.Elem())
-		if err := set(t.Elem(), ptr.Elem(), value, sliceSeparator); err != nil {
+		if err := set(t.Elem(), ptr.Elem(), value, tf); err != nil {
 			return err
 		}
 		f.Set(ptr)
 	case reflect.String:
 		f.SetString(value)
 	case reflect.Bool:
-		v, err := strconv.ParseBool(value)
+		v, err := parseBool(value, tf.Lenient)
 		if err != nil {
 			return err
 		}
@@ -283,8 +1986,28 @@ func set(t reflect.Type, f reflect.Value, value, sliceSeparator string) error {
 			return err
 		}
 		f.SetFloat(v)
+	case reflect.Complex64, reflect.Complex128:
+		v, err := strconv.ParseComplex(value, t.Bits())
+		if err != nil {
+			return err
+		}
+		f.SetComplex(v)
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		if t.PkgPath() == "time" && t.Name() == "Duration" {
+			if tf.Unit != "" {
+				unit, ok := durationUnits[tf.Unit]
+				if !ok {
+					return fmt.Errorf("invalid duration unit: %s", tf.Unit)
+				}
+
+				// Bare numbers (integer or float) are interpreted in the given unit;
+				// anything else falls through to time.ParseDuration.
+				if n, err := strconv.ParseFloat(value, 64); err == nil {
+					f.Set(reflect.ValueOf(time.Duration(n * float64(unit))))
+					break
+				}
+			}
+
 			duration, err := time.ParseDuration(value)
 			if err != nil {
 				return err
@@ -294,56 +2017,96 @@ func set(t reflect.Type, f reflect.Value, value, sliceSeparator string) error {
 			break
 		}
 
-		v, err := strconv.Atoi(value)
+		if tf.Unit == unitBytes {
+			size, err := parseByteSize(value)
+			if err != nil {
+				return err
+			}
+			if size > math.MaxInt64 || f.OverflowInt(int64(size)) {
+				return fmt.Errorf("byte size %q overflows %s", value, t)
+			}
+			f.SetInt(int64(size))
+			break
+		}
+
+		v, err := strconv.ParseInt(value, intBase(value), t.Bits())
 		if err != nil {
 			return err
 		}
-		f.SetInt(int64(v))
+		f.SetInt(v)
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		v, err := strconv.ParseUint(value, 10, 64)
+		if tf.Unit == unitBytes {
+			size, err := parseByteSize(value)
+			if err != nil {
+				return err
+			}
+			if f.OverflowUint(size) {
+				return fmt.Errorf("byte size %q overflows %s", value, t)
+			}
+			f.SetUint(size)
+			break
+		}
+
+		v, err := strconv.ParseUint(value, intBase(value), t.Bits())
 		if err != nil {
 			return err
 		}
 		f.SetUint(v)
 	case reflect.Slice:
-		if sliceSeparator == "" {
-			sliceSeparator = Separator
-		}
-		values := strings.Split(value, sliceSeparator)
-		switch t.Elem().Kind() {
-		case reflect.String:
-			f.Set(reflect.ValueOf(values))
+		values := tf.split(value)
+		var dest reflect.Value
+		switch {
+		case t.Elem().Kind() == reflect.String && tf.Encoding == "":
+			dest = reflect.ValueOf(values)
 		default:
-			dest := reflect.MakeSlice(reflect.SliceOf(t.Elem()), len(values), len(values))
+			dest = reflect.MakeSlice(reflect.SliceOf(t.Elem()), len(values), len(values))
 			for i, v := range values {
-				if err := set(t.Elem(), dest.Index(i), v, sliceSeparator); err != nil {
+				if err := set(t.Elem(), dest.Index(i), v, tf.inner()); err != nil {
 					return err
 				}
 			}
-			f.Set(dest)
 		}
-	case reflect.Map:
-		if sliceSeparator == "" {
-			sliceSeparator = Separator
+		if tf.Unique {
+			dest = uniqueSlice(dest)
 		}
-		if t.Key().Kind() != reflect.String {
-			return ErrUnsupportedType
+		f.Set(dest)
+	case reflect.Array:
+		values := tf.split(value)
+		if len(values) != t.Len() {
+			return fmt.Errorf("expected %d elements for %s, got %d", t.Len(), t, len(values))
 		}
+		dest := reflect.New(t).Elem()
+		for i, v := range values {
+			if err := set(t.Elem(), dest.Index(i), v, tf.inner()); err != nil {
+				return err
+			}
+		}
+		f.Set(dest)
+	case reflect.Map:
 		dest := reflect.MakeMap(t)
 		if value == "" {
 			f.Set(dest)
 			return nil
 		}
-		pairs := strings.Split(value, sliceSeparator)
+		pairs := tf.split(value)
 		for _, pair := range pairs {
-			kv := strings.SplitN(pair, ":", 2)
+			kv := strings.SplitN(pair, tf.kvSeparator(), 2)
 			if len(kv) != 2 {
 				return fmt.Errorf("invalid map entry: %s", pair)
 			}
+			if tf.Trim {
+				kv[0], kv[1] = strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+			}
+			if tf.LowerKeys {
+				kv[0] = strings.ToLower(kv[0])
+			}
+			// Tag options describe the values, so keys are parsed without them.
 			keyVal := reflect.New(t.Key()).Elem()
-			keyVal.SetString(kv[0])
+			if err := set(t.Key(), keyVal, kv[0], tagField{}); err != nil {
+				return fmt.Errorf("invalid map key %q: %w", kv[0], err)
+			}
 			valVal := reflect.New(t.Elem()).Elem()
-			if err := set(t.Elem(), valVal, kv[1], sliceSeparator); err != nil {
+			if err := set(t.Elem(), valVal, kv[1], tf.inner()); err != nil {
 				return err
 			}
 			dest.SetMapIndex(keyVal, valVal)
@@ -2,7 +2,17 @@ package envParser
 
 import (
 	"errors"
+	"fmt"
+	"maps"
+	"math/big"
+	"net"
+	"net/netip"
+	"net/url"
 	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 )
@@ -47,11 +57,12 @@ func TestParseEnvFile(t *testing.T) {
 		{"empty lines", "KEY=value\n\nFOO=bar", []string{"KEY=value", "FOO=bar"}},
 		{"windows crlf", "KEY=value\r\nFOO=bar\r\n", []string{"KEY=value", "FOO=bar"}},
 		{"whitespace", "  KEY=value  \n  # comment  ", []string{"KEY=value"}},
+		{"bare key", "DEBUG\nKEY=value", []string{"DEBUG=", "KEY=value"}},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := parseEnvFile(tt.content)
+			got, _ := parseEnvFile("", tt.content)
 			if len(got) != len(tt.want) {
 				t.Errorf("parseEnvFile() = %v, want %v", got, tt.want)
 			}
@@ -543,3 +554,2347 @@ func TestValidatorThreadSafety(t *testing.T) {
 		<-done
 	}
 }
+
+func TestUnmarshalMapLowerKeys(t *testing.T) {
+	type Config struct {
+		Headers map[string]string `env:"HEADERS,lowerkeys"`
+		Plain   map[string]string `env:"PLAIN"`
+	}
+
+	envs := map[string]string{
+		"HEADERS": "Content-Type:Application/JSON;X-Request-ID:AbC",
+		"PLAIN":   "Content-Type:json",
+	}
+	var cfg Config
+	if err := Unmarshal(envs, &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if cfg.Headers["content-type"] != "Application/JSON" || cfg.Headers["x-request-id"] != "AbC" {
+		t.Errorf("Headers = %v", cfg.Headers)
+	}
+	if _, ok := cfg.Headers["Content-Type"]; ok {
+		t.Errorf("Headers should not contain original-case key: %v", cfg.Headers)
+	}
+	if cfg.Plain["Content-Type"] != "json" {
+		t.Errorf("Plain = %v, keys should keep their case without lowerkeys", cfg.Plain)
+	}
+}
+
+func TestUnmarshalDurationUnit(t *testing.T) {
+	type Config struct {
+		Timeout  time.Duration `env:"TIMEOUT,unit=s"`
+		Interval time.Duration `env:"INTERVAL,unit=ms"`
+		Backoff  time.Duration `env:"BACKOFF,unit=s"`
+	}
+
+	envs := map[string]string{
+		"TIMEOUT":  "1.5",
+		"INTERVAL": "250",
+		"BACKOFF":  "2m",
+	}
+	var cfg Config
+	if err := Unmarshal(envs, &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if cfg.Timeout != 1500*time.Millisecond {
+		t.Errorf("Timeout = %v, want 1.5s", cfg.Timeout)
+	}
+	if cfg.Interval != 250*time.Millisecond {
+		t.Errorf("Interval = %v, want 250ms", cfg.Interval)
+	}
+	if cfg.Backoff != 2*time.Minute {
+		t.Errorf("Backoff = %v, want 2m", cfg.Backoff)
+	}
+}
+
+func TestUnmarshalDurationInvalidUnit(t *testing.T) {
+	type Config struct {
+		Timeout time.Duration `env:"TIMEOUT,unit=days"`
+	}
+
+	var cfg Config
+	if err := Unmarshal(map[string]string{"TIMEOUT": "1"}, &cfg); err == nil {
+		t.Error("expected error for invalid duration unit")
+	}
+}
+
+func TestUnmarshalPointerToCollections(t *testing.T) {
+	type Config struct {
+		Ports   *[]int             `env:"PORTS"`
+		Labels  *map[string]string `env:"LABELS"`
+		Absent  *[]int             `env:"ABSENT_PORTS"`
+		NoLabel *map[string]string `env:"ABSENT_LABELS"`
+	}
+
+	envs := map[string]string{
+		"PORTS":  "80;443",
+		"LABELS": "env:prod;region:us",
+	}
+	var cfg Config
+	if err := Unmarshal(envs, &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if cfg.Ports == nil || len(*cfg.Ports) != 2 || (*cfg.Ports)[0] != 80 || (*cfg.Ports)[1] != 443 {
+		t.Errorf("Ports = %v", cfg.Ports)
+	}
+	if cfg.Labels == nil || (*cfg.Labels)["env"] != "prod" || (*cfg.Labels)["region"] != "us" {
+		t.Errorf("Labels = %v", cfg.Labels)
+	}
+	if cfg.Absent != nil {
+		t.Errorf("Absent = %v, want nil", cfg.Absent)
+	}
+	if cfg.NoLabel != nil {
+		t.Errorf("NoLabel = %v, want nil", cfg.NoLabel)
+	}
+}
+
+func TestUnmarshalPointerToEmptyMap(t *testing.T) {
+	type Config struct {
+		Labels *map[string]string `env:"LABELS"`
+	}
+
+	var cfg Config
+	if err := Unmarshal(map[string]string{"LABELS": ""}, &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if cfg.Labels == nil || *cfg.Labels == nil || len(*cfg.Labels) != 0 {
+		t.Errorf("Labels = %v, want pointer to empty map", cfg.Labels)
+	}
+}
+
+func TestUnmarshalStrictTagOptions(t *testing.T) {
+	defer func() { Strict = false }()
+
+	type Config struct {
+		Host string `env:"HOST,separator=\\,"`
+	}
+	envs := map[string]string{"HOST": "a,b"}
+
+	t.Run("lenient ignores separator on scalar", func(t *testing.T) {
+		Strict = false
+
+		var cfg Config
+		if err := Unmarshal(envs, &cfg); err != nil {
+			t.Fatalf("Unmarshal() error = %v", err)
+		}
+		if cfg.Host != "a,b" {
+			t.Errorf("Host = %v, want a,b", cfg.Host)
+		}
+	})
+
+	t.Run("strict rejects separator on scalar", func(t *testing.T) {
+		Strict = true
+
+		var cfg Config
+		err := Unmarshal(envs, &cfg)
+		if !errors.Is(err, ErrInvalidTagOption) {
+			t.Errorf("expected ErrInvalidTagOption, got: %v", err)
+		}
+	})
+
+	t.Run("strict rejects lowerkeys on slice", func(t *testing.T) {
+		Strict = true
+
+		type SliceConfig struct {
+			Items []string `env:"ITEMS,lowerkeys"`
+		}
+		var cfg SliceConfig
+		err := Unmarshal(map[string]string{"ITEMS": "a"}, &cfg)
+		if !errors.Is(err, ErrInvalidTagOption) {
+			t.Errorf("expected ErrInvalidTagOption, got: %v", err)
+		}
+	})
+
+	t.Run("strict allows collection options on collections", func(t *testing.T) {
+		Strict = true
+
+		type CollectionConfig struct {
+			Items  *[]string         `env:"ITEMS,separator=|"`
+			Labels map[string]string `env:"LABELS,lowerkeys,separator=|"`
+		}
+		var cfg CollectionConfig
+		if err := Unmarshal(map[string]string{"ITEMS": "a|b", "LABELS": "A:1|B:2"}, &cfg); err != nil {
+			t.Fatalf("Unmarshal() error = %v", err)
+		}
+	})
+}
+
+func TestUnmarshalKeyNormalizeReplacer(t *testing.T) {
+	defer func() { KeyNormalizeReplacer = nil }()
+
+	type Config struct {
+		Host string `env:"DB_HOST"`
+		Port int    `env:"DB.PORT"`
+	}
+
+	t.Run("normalized keys match", func(t *testing.T) {
+		KeyNormalizeReplacer = strings.NewReplacer(".", "_", "-", "_")
+
+		for _, envs := range []map[string]string{
+			{"DB-HOST": "dashed", "DB_PORT": "5432"},
+			{"DB.HOST": "dotted", "DB-PORT": "5432"},
+		} {
+			var cfg Config
+			if err := Unmarshal(envs, &cfg); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if cfg.Host == "" || cfg.Port != 5432 {
+				t.Errorf("Unmarshal(%v) = %+v", envs, cfg)
+			}
+		}
+	})
+
+	t.Run("exact match preferred", func(t *testing.T) {
+		KeyNormalizeReplacer = strings.NewReplacer(".", "_", "-", "_")
+
+		var cfg Config
+		if err := Unmarshal(map[string]string{"DB_HOST": "exact", "DB-HOST": "dashed"}, &cfg); err != nil {
+			t.Fatalf("Unmarshal() error = %v", err)
+		}
+		if cfg.Host != "exact" {
+			t.Errorf("Host = %v, want exact", cfg.Host)
+		}
+	})
+
+	t.Run("no replacer", func(t *testing.T) {
+		KeyNormalizeReplacer = nil
+
+		var cfg Config
+		if err := Unmarshal(map[string]string{"DB-HOST": "dashed"}, &cfg); err != nil {
+			t.Fatalf("Unmarshal() error = %v", err)
+		}
+		if cfg.Host != "" {
+			t.Errorf("Host = %v, want empty without replacer", cfg.Host)
+		}
+	})
+}
+
+func TestEnvironToMapDuplicates(t *testing.T) {
+	defer func() { Duplicates = LastWins }()
+
+	env := []string{"KEY=first", "OTHER=x", "KEY=last"}
+
+	tests := []struct {
+		mode DuplicateMode
+		want string
+	}{
+		{LastWins, "last"},
+		{FirstWins, "first"},
+	}
+
+	for _, tt := range tests {
+		Duplicates = tt.mode
+		got, err := EnvironToMap(env)
+		if err != nil {
+			t.Fatalf("EnvironToMap() error = %v", err)
+		}
+		if got["KEY"] != tt.want || got["OTHER"] != "x" {
+			t.Errorf("mode %d: EnvironToMap() = %v, want KEY=%s", tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestUnmarshalFromFileDuplicates(t *testing.T) {
+	defer func() { Duplicates = LastWins }()
+
+	f, err := os.CreateTemp("", "env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString("DUP_KEY=file_value\n")
+	f.Close()
+
+	os.Setenv("DUP_KEY", "system_value")
+	defer os.Unsetenv("DUP_KEY")
+
+	type Config struct {
+		Key string `env:"DUP_KEY"`
+	}
+
+	tests := []struct {
+		mode DuplicateMode
+		want string
+	}{
+		{LastWins, "file_value"},
+		{FirstWins, "system_value"},
+	}
+
+	for _, tt := range tests {
+		Duplicates = tt.mode
+		var cfg Config
+		if err := UnmarshalFromFile(f.Name(), &cfg); err != nil {
+			t.Fatalf("UnmarshalFromFile() error = %v", err)
+		}
+		if cfg.Key != tt.want {
+			t.Errorf("mode %d: Key = %v, want %v", tt.mode, cfg.Key, tt.want)
+		}
+	}
+}
+
+func TestUnmarshalExclusiveWith(t *testing.T) {
+	type Config struct {
+		URL  string `env:"DATABASE_URL,exclusive_with=DB_HOST"`
+		Host string `env:"DB_HOST,exclusive_with=DATABASE_URL"`
+	}
+
+	tests := []struct {
+		name    string
+		envs    map[string]string
+		wantErr bool
+	}{
+		{"both present", map[string]string{"DATABASE_URL": "postgres://x", "DB_HOST": "x"}, true},
+		{"url only", map[string]string{"DATABASE_URL": "postgres://x"}, false},
+		{"host only", map[string]string{"DB_HOST": "x"}, false},
+		{"neither", map[string]string{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg Config
+			err := Unmarshal(tt.envs, &cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUnmarshalExclusiveWithDefault(t *testing.T) {
+	type Config struct {
+		Mode  string `env:"MODE,default=auto,exclusive_with=LEGACY_MODE"`
+		Other string `env:"LEGACY_MODE"`
+	}
+
+	var cfg Config
+	m, err := UnmarshalWithMetrics(map[string]string{"LEGACY_MODE": "x"}, &cfg)
+	if err != nil {
+		t.Errorf("defaults should not count as present: %v", err)
+	}
+	if m.Errors != 0 {
+		t.Errorf("Errors = %d, want 0", m.Errors)
+	}
+}
+
+func TestUnmarshalDurationZeroAndEmpty(t *testing.T) {
+	type Config struct {
+		Bare     time.Duration `env:"BARE"`
+		Suffixed time.Duration `env:"SUFFIXED"`
+		Empty    time.Duration `env:"EMPTY,default=30s"`
+		Missing  time.Duration `env:"MISSING,default=1m"`
+	}
+
+	envs := map[string]string{
+		"BARE":     "0",
+		"SUFFIXED": "0s",
+		"EMPTY":    "",
+	}
+	cfg := Config{Bare: time.Hour, Suffixed: time.Hour}
+	if err := Unmarshal(envs, &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if cfg.Bare != 0 || cfg.Suffixed != 0 {
+		t.Errorf("Bare = %v, Suffixed = %v, want 0", cfg.Bare, cfg.Suffixed)
+	}
+	if cfg.Empty != 30*time.Second {
+		t.Errorf("Empty = %v, want default 30s", cfg.Empty)
+	}
+	if cfg.Missing != time.Minute {
+		t.Errorf("Missing = %v, want default 1m", cfg.Missing)
+	}
+}
+
+func TestUnmarshalEmptyDurationWithoutDefault(t *testing.T) {
+	type Config struct {
+		Timeout time.Duration `env:"TIMEOUT"`
+	}
+
+	var cfg Config
+	if err := Unmarshal(map[string]string{"TIMEOUT": ""}, &cfg); err == nil {
+		t.Error("expected error for empty duration without default")
+	}
+}
+
+func TestUnmarshalEmptyStringWithDefault(t *testing.T) {
+	type Config struct {
+		Name   string `env:"NAME,default=anon"`
+		APIKey string `env:"API_KEY,notEmpty,default=dev"`
+	}
+
+	var cfg Config
+	if err := Unmarshal(map[string]string{"NAME": "", "API_KEY": ""}, &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if cfg.Name != "" {
+		t.Errorf("Name = %q, want empty string to override the default", cfg.Name)
+	}
+	if cfg.APIKey != "dev" {
+		t.Errorf("APIKey = %q, want %q", cfg.APIKey, "dev")
+	}
+}
+
+func TestUnknownKeyHook(t *testing.T) {
+	defer SetUnknownKeyHook(nil)
+
+	type Config struct {
+		Host string `env:"HOST"`
+		Port int    `env:"PORT,default=8080"`
+	}
+
+	var unknown []string
+	SetUnknownKeyHook(func(key, value string) {
+		unknown = append(unknown, key+"="+value)
+	})
+
+	envs := map[string]string{"HOST": "localhost", "EXTRA_B": "2", "EXTRA_A": "1"}
+	var cfg Config
+	if err := Unmarshal(envs, &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if len(unknown) != 2 || unknown[0] != "EXTRA_A=1" || unknown[1] != "EXTRA_B=2" {
+		t.Errorf("unknown keys = %v, want [EXTRA_A=1 EXTRA_B=2]", unknown)
+	}
+}
+
+func TestUnknownKeyHookThreadSafety(t *testing.T) {
+	defer SetUnknownKeyHook(nil)
+
+	type Config struct {
+		Name string `env:"NAME"`
+	}
+
+	done := make(chan bool)
+	for i := 0; i < 10; i++ {
+		go func() {
+			for j := 0; j < 100; j++ {
+				SetUnknownKeyHook(func(key, value string) {})
+				SetUnknownKeyHook(nil)
+			}
+			done <- true
+		}()
+		go func() {
+			for j := 0; j < 100; j++ {
+				var cfg Config
+				_ = Unmarshal(map[string]string{"NAME": "test", "OTHER": "x"}, &cfg)
+			}
+			done <- true
+		}()
+	}
+
+	for i := 0; i < 20; i++ {
+		<-done
+	}
+}
+
+func TestUnmarshalUniqueSlice(t *testing.T) {
+	type Config struct {
+		Hosts []string `env:"HOSTS,unique"`
+		Ports []int    `env:"PORTS,unique"`
+		All   []string `env:"ALL"`
+	}
+
+	envs := map[string]string{
+		"HOSTS": "b;a;b;c;a",
+		"PORTS": "443;80;0443;80",
+		"ALL":   "a;a",
+	}
+	var cfg Config
+	if err := Unmarshal(envs, &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if len(cfg.Hosts) != 3 || cfg.Hosts[0] != "b" || cfg.Hosts[1] != "a" || cfg.Hosts[2] != "c" {
+		t.Errorf("Hosts = %v, want [b a c]", cfg.Hosts)
+	}
+	if len(cfg.Ports) != 2 || cfg.Ports[0] != 443 || cfg.Ports[1] != 80 {
+		t.Errorf("Ports = %v, want [443 80]", cfg.Ports)
+	}
+	if len(cfg.All) != 2 {
+		t.Errorf("All = %v, duplicates should be kept without unique", cfg.All)
+	}
+}
+
+func TestRequireKeys(t *testing.T) {
+	envs := map[string]string{"HOST": "localhost", "PORT": ""}
+
+	if err := RequireKeys(envs, []string{"HOST", "PORT"}); err != nil {
+		t.Errorf("RequireKeys() all present error = %v", err)
+	}
+
+	err := RequireKeys(envs, []string{"HOST", "USER", "PASSWORD"})
+	if err == nil {
+		t.Fatal("expected error for missing keys")
+	}
+
+	msg := err.Error()
+	if !strings.Contains(msg, "USER") || !strings.Contains(msg, "PASSWORD") || strings.Contains(msg, "HOST") {
+		t.Errorf("RequireKeys() error = %q, want USER and PASSWORD listed", msg)
+	}
+}
+
+func TestUnmarshalNestedTagKey(t *testing.T) {
+	type Cache struct {
+		Host string `cfg:"CACHE_HOST" env:"WRONG_HOST"`
+		TTL  int    `cfg:"CACHE_TTL,default=60"`
+	}
+	type Inner struct {
+		Name string `env:"INNER_NAME" cfg:"WRONG_NAME"`
+	}
+	type Config struct {
+		Name  string `env:"APP_NAME"`
+		Cache Cache  `envTag:"cfg"`
+		Inner Inner
+	}
+
+	envs := map[string]string{
+		"APP_NAME":   "app",
+		"CACHE_HOST": "redis",
+		"WRONG_HOST": "wrong",
+		"INNER_NAME": "inner",
+		"WRONG_NAME": "wrong",
+	}
+	var cfg Config
+	if err := Unmarshal(envs, &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if cfg.Name != "app" || cfg.Cache.Host != "redis" || cfg.Cache.TTL != 60 || cfg.Inner.Name != "inner" {
+		t.Errorf("Unmarshal() = %+v", cfg)
+	}
+}
+
+func TestUnmarshalCompactStruct(t *testing.T) {
+	type Addr struct {
+		Host string `env:"host,required"`
+		Port int    `env:"port,default=80"`
+	}
+	type Config struct {
+		Addr  Addr `env:"ADDR,struct"`
+		Proxy Addr `env:"PROXY,struct,separator=;"`
+	}
+
+	t.Run("decodes pairs", func(t *testing.T) {
+		envs := map[string]string{
+			"ADDR":  "host=localhost,port=8080",
+			"PROXY": "host=proxy",
+		}
+		var cfg Config
+		if err := Unmarshal(envs, &cfg); err != nil {
+			t.Fatalf("Unmarshal() error = %v", err)
+		}
+
+		if cfg.Addr.Host != "localhost" || cfg.Addr.Port != 8080 {
+			t.Errorf("Addr = %+v", cfg.Addr)
+		}
+		if cfg.Proxy.Host != "proxy" || cfg.Proxy.Port != 80 {
+			t.Errorf("Proxy = %+v", cfg.Proxy)
+		}
+	})
+
+	t.Run("top-level keys are not used", func(t *testing.T) {
+		envs := map[string]string{
+			"ADDR":  "port=8080",
+			"PROXY": "host=proxy",
+			"host":  "global",
+		}
+		var cfg Config
+		if err := Unmarshal(envs, &cfg); err == nil {
+			t.Errorf("expected required error for ADDR host, got %+v", cfg)
+		}
+	})
+
+	t.Run("unknown and malformed entries", func(t *testing.T) {
+		for _, value := range []string{"host=a,user=b", "host=a,port"} {
+			var cfg Config
+			if err := Unmarshal(map[string]string{"ADDR": value, "PROXY": "host=p"}, &cfg); err == nil {
+				t.Errorf("expected error for ADDR=%q", value)
+			}
+		}
+	})
+
+	t.Run("parse errors are not unknown keys", func(t *testing.T) {
+		var cfg Config
+		err := Unmarshal(map[string]string{"ADDR": "host=x,port=abc", "PROXY": "host=p"}, &cfg)
+		if err == nil || strings.Contains(err.Error(), "unknown key") || !strings.Contains(err.Error(), "abc") {
+			t.Errorf("Unmarshal() error = %v, want only the port parse error", err)
+		}
+	})
+}
+
+func TestUnmarshalCompactStructStrict(t *testing.T) {
+	defer func() { Strict = false }()
+	Strict = true
+
+	type Addr struct {
+		Host string `env:"host"`
+	}
+	type Config struct {
+		Addr Addr   `env:"ADDR,struct,separator=;"`
+		Name string `env:"NAME,struct"`
+	}
+
+	var cfg Config
+	err := Unmarshal(map[string]string{"ADDR": "host=a", "NAME": "x"}, &cfg)
+	if !errors.Is(err, ErrInvalidTagOption) {
+		t.Errorf("expected ErrInvalidTagOption for struct option on string, got: %v", err)
+	}
+	if cfg.Addr.Host != "a" {
+		t.Errorf("Addr = %+v, separator should be allowed with struct", cfg.Addr)
+	}
+}
+
+func TestUnmarshalPresence(t *testing.T) {
+	type Config struct {
+		Debug   bool `env:"DEBUG,presence"`
+		Verbose bool `env:"VERBOSE,presence"`
+		Quiet   bool `env:"QUIET,presence"`
+		Trace   bool `env:"TRACE,presence,default=true"`
+	}
+
+	envs := map[string]string{"DEBUG": "", "VERBOSE": "false"}
+	var cfg Config
+	if err := Unmarshal(envs, &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if !cfg.Debug {
+		t.Error("Debug should be true when key is present without value")
+	}
+	if cfg.Verbose {
+		t.Error("Verbose should honor an explicit false value")
+	}
+	if cfg.Quiet {
+		t.Error("Quiet should be false when key is absent")
+	}
+	if !cfg.Trace {
+		t.Error("Trace should use its default when key is absent")
+	}
+}
+
+func TestUnmarshalFromFileBareBoolKey(t *testing.T) {
+	f, err := os.CreateTemp("", "env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString("# flags\nBARE_DEBUG\nBARE_NAME=app\n")
+	f.Close()
+
+	type Config struct {
+		Debug bool   `env:"BARE_DEBUG,presence"`
+		Name  string `env:"BARE_NAME"`
+	}
+
+	var cfg Config
+	if err := UnmarshalFromFileOnly(f.Name(), &cfg); err != nil {
+		t.Fatalf("UnmarshalFromFileOnly() error = %v", err)
+	}
+
+	if !cfg.Debug || cfg.Name != "app" {
+		t.Errorf("Unmarshal() = %+v, want Debug=true Name=app", cfg)
+	}
+}
+
+type testLevel int
+
+func (l *testLevel) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "debug":
+		*l = 1
+	case "info":
+		*l = 2
+	default:
+		return fmt.Errorf("unknown level %q", text)
+	}
+	return nil
+}
+
+func TestUnmarshalTextUnmarshaler(t *testing.T) {
+	type Config struct {
+		Level  testLevel            `env:"LEVEL"`
+		Ptr    *testLevel           `env:"PTR_LEVEL"`
+		Levels []testLevel          `env:"LEVELS"`
+		ByName map[string]testLevel `env:"BY_NAME"`
+		Addr   netip.Addr           `env:"ADDR"`
+		IP     net.IP               `env:"IP"`
+	}
+
+	envs := map[string]string{
+		"LEVEL":     "debug",
+		"PTR_LEVEL": "info",
+		"LEVELS":    "info;debug",
+		"BY_NAME":   "api:debug",
+		"ADDR":      "10.0.0.1",
+		"IP":        "::1",
+	}
+	var cfg Config
+	if err := Unmarshal(envs, &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if cfg.Level != 1 || cfg.Ptr == nil || *cfg.Ptr != 2 {
+		t.Errorf("Level = %v, Ptr = %v", cfg.Level, cfg.Ptr)
+	}
+	if len(cfg.Levels) != 2 || cfg.Levels[0] != 2 || cfg.ByName["api"] != 1 {
+		t.Errorf("Levels = %v, ByName = %v", cfg.Levels, cfg.ByName)
+	}
+	if cfg.Addr != netip.MustParseAddr("10.0.0.1") || !cfg.IP.Equal(net.IPv6loopback) {
+		t.Errorf("Addr = %v, IP = %v", cfg.Addr, cfg.IP)
+	}
+
+	if err := Unmarshal(map[string]string{"LEVEL": "trace"}, &cfg); err == nil {
+		t.Error("expected error from UnmarshalText")
+	}
+}
+
+func TestUnmarshalEnvPrefix(t *testing.T) {
+	type Conn struct {
+		Host string `env:"HOST,required"`
+		Port int    `env:"PORT,default=5432"`
+	}
+	type Replica struct {
+		Conn Conn `envPrefix:"REPLICA_"`
+	}
+	type Config struct {
+		Primary Conn    `envPrefix:"DB_"`
+		Replica Replica `envPrefix:"DB_"`
+		Name    string  `env:"NAME"`
+	}
+
+	envs := map[string]string{
+		"DB_HOST":         "primary",
+		"DB_PORT":         "5433",
+		"DB_REPLICA_HOST": "replica",
+		"NAME":            "app",
+	}
+	var cfg Config
+	if err := Unmarshal(envs, &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if cfg.Primary.Host != "primary" || cfg.Primary.Port != 5433 {
+		t.Errorf("Primary = %+v", cfg.Primary)
+	}
+	if cfg.Replica.Conn.Host != "replica" || cfg.Replica.Conn.Port != 5432 {
+		t.Errorf("Replica = %+v", cfg.Replica.Conn)
+	}
+	if cfg.Name != "app" {
+		t.Errorf("Name = %v", cfg.Name)
+	}
+
+	err := Unmarshal(map[string]string{"HOST": "unprefixed", "DB_HOST": "x"}, &cfg)
+	if err == nil || !strings.Contains(err.Error(), "DB_REPLICA_HOST") {
+		t.Errorf("expected required error naming DB_REPLICA_HOST, got: %v", err)
+	}
+}
+
+func TestSpecEnvPrefix(t *testing.T) {
+	type Conn struct {
+		Host string `env:"HOST"`
+	}
+	type Config struct {
+		DB Conn `envPrefix:"DB_"`
+	}
+
+	specs, err := fieldSpecs(&Config{})
+	if err != nil {
+		t.Fatalf("fieldSpecs() error = %v", err)
+	}
+	if len(specs) != 1 || specs[0].Key != "DB_HOST" {
+		t.Errorf("fieldSpecs() = %+v, want DB_HOST", specs)
+	}
+}
+
+func TestUnmarshalTime(t *testing.T) {
+	type Config struct {
+		Start   time.Time   `env:"START"`
+		Date    time.Time   `env:"DATE,layout=2006-01-02"`
+		Pretty  time.Time   `env:"PRETTY,layout=Jan 2\\, 2006"`
+		Unix    time.Time   `env:"UNIX,layout=unix"`
+		Millis  *time.Time  `env:"MILLIS,layout=unixmilli"`
+		Holiday []time.Time `env:"HOLIDAYS,layout=2006-01-02"`
+	}
+
+	envs := map[string]string{
+		"START":    "2024-03-01T12:30:00Z",
+		"DATE":     "2024-03-01",
+		"PRETTY":   "Mar 1, 2024",
+		"UNIX":     "1709296200",
+		"MILLIS":   "1709296200500",
+		"HOLIDAYS": "2024-12-25;2025-01-01",
+	}
+	var cfg Config
+	if err := Unmarshal(envs, &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	start := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	date := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	if !cfg.Start.Equal(start) {
+		t.Errorf("Start = %v, want %v", cfg.Start, start)
+	}
+	if !cfg.Date.Equal(date) || !cfg.Pretty.Equal(date) {
+		t.Errorf("Date = %v, Pretty = %v, want %v", cfg.Date, cfg.Pretty, date)
+	}
+	if !cfg.Unix.Equal(start) {
+		t.Errorf("Unix = %v, want %v", cfg.Unix, start)
+	}
+	if cfg.Millis == nil || !cfg.Millis.Equal(start.Add(500*time.Millisecond)) {
+		t.Errorf("Millis = %v, want %v", cfg.Millis, start.Add(500*time.Millisecond))
+	}
+	if len(cfg.Holiday) != 2 || cfg.Holiday[1].Year() != 2025 {
+		t.Errorf("Holiday = %v", cfg.Holiday)
+	}
+}
+
+func TestUnmarshalTimeErrors(t *testing.T) {
+	type Config struct {
+		RFC3339 time.Time `env:"RFC3339"`
+		Date    time.Time `env:"DATE,layout=2006-01-02"`
+		Unix    time.Time `env:"UNIX,layout=unix"`
+	}
+
+	for key, value := range map[string]string{"RFC3339": "2024-03-01", "DATE": "01/03/2024", "UNIX": "yesterday"} {
+		var cfg Config
+		if err := Unmarshal(map[string]string{key: value}, &cfg); err == nil {
+			t.Errorf("expected error for %s=%s", key, value)
+		}
+	}
+}
+
+func TestUnmarshalURL(t *testing.T) {
+	type Config struct {
+		API     url.URL    `env:"API_URL,schemes=http|https"`
+		Webhook *url.URL   `env:"WEBHOOK_URL"`
+		Mirrors []*url.URL `env:"MIRRORS,schemes=https"`
+	}
+
+	envs := map[string]string{
+		"API_URL":     "HTTPS://api.example.com/v1?debug=1",
+		"WEBHOOK_URL": "amqp://guest@queue:5672/",
+		"MIRRORS":     "https://a.example.com;https://b.example.com",
+	}
+	var cfg Config
+	if err := Unmarshal(envs, &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if cfg.API.Host != "api.example.com" || cfg.API.Path != "/v1" || cfg.API.Query().Get("debug") != "1" {
+		t.Errorf("API = %+v", cfg.API)
+	}
+	if cfg.Webhook == nil || cfg.Webhook.Scheme != "amqp" || cfg.Webhook.User.Username() != "guest" {
+		t.Errorf("Webhook = %+v", cfg.Webhook)
+	}
+	if len(cfg.Mirrors) != 2 || cfg.Mirrors[1].Host != "b.example.com" {
+		t.Errorf("Mirrors = %v", cfg.Mirrors)
+	}
+}
+
+func TestUnmarshalURLErrors(t *testing.T) {
+	type Config struct {
+		API url.URL `env:"API_URL,schemes=http|https"`
+	}
+
+	for _, value := range []string{"ftp://files.example.com", "http://[::1", "api.example.com"} {
+		var cfg Config
+		if err := Unmarshal(map[string]string{"API_URL": value}, &cfg); err == nil {
+			t.Errorf("expected error for %q", value)
+		}
+	}
+}
+
+func TestUnmarshalNetworkTypes(t *testing.T) {
+	type Config struct {
+		IP      net.IP       `env:"IP"`
+		Addr    netip.Addr   `env:"ADDR"`
+		Prefix  netip.Prefix `env:"PREFIX"`
+		Network net.IPNet    `env:"NETWORK"`
+		Allowed []*net.IPNet `env:"ALLOWED"`
+	}
+
+	envs := map[string]string{
+		"IP":      "192.168.1.10",
+		"ADDR":    "fe80::1",
+		"PREFIX":  "10.0.0.0/8",
+		"NETWORK": "192.168.1.10/24",
+		"ALLOWED": "10.0.0.0/8;2001:db8::/32",
+	}
+	var cfg Config
+	if err := Unmarshal(envs, &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if !cfg.IP.Equal(net.IPv4(192, 168, 1, 10)) || cfg.Addr != netip.MustParseAddr("fe80::1") {
+		t.Errorf("IP = %v, Addr = %v", cfg.IP, cfg.Addr)
+	}
+	if cfg.Prefix != netip.MustParsePrefix("10.0.0.0/8") {
+		t.Errorf("Prefix = %v", cfg.Prefix)
+	}
+	if cfg.Network.String() != "192.168.1.0/24" {
+		t.Errorf("Network = %v, want 192.168.1.0/24", cfg.Network.String())
+	}
+	if len(cfg.Allowed) != 2 || !cfg.Allowed[1].Contains(net.ParseIP("2001:db8::1")) {
+		t.Errorf("Allowed = %v", cfg.Allowed)
+	}
+}
+
+func TestUnmarshalNetworkTypesErrors(t *testing.T) {
+	type Config struct {
+		IP      net.IP       `env:"IP"`
+		Addr    netip.Addr   `env:"ADDR"`
+		Prefix  netip.Prefix `env:"PREFIX"`
+		Network net.IPNet    `env:"NETWORK"`
+	}
+
+	for key, value := range map[string]string{"IP": "300.1.1.1", "ADDR": "localhost", "PREFIX": "10.0.0.0", "NETWORK": "10.0.0.0/33"} {
+		var cfg Config
+		if err := Unmarshal(map[string]string{key: value}, &cfg); err == nil {
+			t.Errorf("expected error for %s=%s", key, value)
+		}
+	}
+}
+
+func TestUnmarshalBytesEncoding(t *testing.T) {
+	type Config struct {
+		Std    []byte   `env:"STD,encoding=base64"`
+		URL    []byte   `env:"URL,encoding=base64url"`
+		Hex    []byte   `env:"HEX,encoding=hex"`
+		Raw    []byte   `env:"RAW,encoding=raw"`
+		Keys   [][]byte `env:"KEYS,encoding=hex"`
+		Legacy []byte   `env:"LEGACY"`
+	}
+
+	envs := map[string]string{
+		"STD":    "aGk/Pz4+",
+		"URL":    "aGk_Pz4-",
+		"HEX":    "DEADbeef",
+		"RAW":    "s3cr3t",
+		"KEYS":   "01;0203",
+		"LEGACY": "1;2",
+	}
+	var cfg Config
+	if err := Unmarshal(envs, &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if string(cfg.Std) != "hi??>>" || string(cfg.URL) != "hi??>>" {
+		t.Errorf("Std = %q, URL = %q", cfg.Std, cfg.URL)
+	}
+	if string(cfg.Hex) != "\xde\xad\xbe\xef" || string(cfg.Raw) != "s3cr3t" {
+		t.Errorf("Hex = %x, Raw = %q", cfg.Hex, cfg.Raw)
+	}
+	if len(cfg.Keys) != 2 || string(cfg.Keys[1]) != "\x02\x03" {
+		t.Errorf("Keys = %x", cfg.Keys)
+	}
+	if string(cfg.Legacy) != "\x01\x02" {
+		t.Errorf("Legacy = %v, want separator-split bytes", cfg.Legacy)
+	}
+
+	// Unpadded base64 is accepted.
+	if err := Unmarshal(map[string]string{"STD": "aGk"}, &cfg); err != nil || string(cfg.Std) != "hi" {
+		t.Errorf("unpadded base64 = %q, %v", cfg.Std, err)
+	}
+}
+
+func TestUnmarshalStringEncoding(t *testing.T) {
+	type Config struct {
+		Password string   `env:"PASSWORD,base64"`
+		Token    string   `env:"TOKEN,encoding=base64url"`
+		Hex      string   `env:"HEX,encoding=hex"`
+		Lines    []string `env:"LINES,base64"`
+		Default  string   `env:"DEFAULT,base64,default=JCpe"`
+	}
+
+	envs := map[string]string{
+		"PASSWORD": "cEAkJHc7cmQ=",
+		"TOKEN":    "aGk_Pz4-",
+		"HEX":      "2461",
+		"LINES":    "YQpi;Yw",
+	}
+	var cfg Config
+	if err := Unmarshal(envs, &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	want := Config{Password: "p@$$w;rd", Token: "hi??>>", Hex: "$a", Lines: []string{"a\nb", "c"}, Default: "$*^"}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("Unmarshal() = %+v, want %+v", cfg, want)
+	}
+
+	if err := Unmarshal(map[string]string{"PASSWORD": "not base64!"}, &cfg); err == nil {
+		t.Error("expected error for invalid base64")
+	}
+}
+
+func TestUnmarshalBytesEncodingErrors(t *testing.T) {
+	type Config struct {
+		Std     []byte `env:"STD,encoding=base64"`
+		Hex     []byte `env:"HEX,encoding=hex"`
+		Unknown []byte `env:"UNKNOWN,encoding=base32"`
+	}
+
+	for key, value := range map[string]string{"STD": "not base64!", "HEX": "xyz", "UNKNOWN": "MZXW6==="} {
+		var cfg Config
+		if err := Unmarshal(map[string]string{key: value}, &cfg); err == nil {
+			t.Errorf("expected error for %s=%s", key, value)
+		}
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    uint64
+		wantErr bool
+	}{
+		{"1024", 1024, false},
+		{"512KB", 512000, false},
+		{"512kb", 512000, false},
+		{"10MiB", 10 << 20, false},
+		{"2G", 2e9, false},
+		{"2Gi", 2 << 30, false},
+		{"1.5 GB", 1.5e9, false},
+		{"0.5KiB", 512, false},
+		{"100B", 100, false},
+		{"", 0, true},
+		{"MB", 0, true},
+		{"10XB", 0, true},
+		{"-1KB", 0, true},
+		{"20000000PB", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseByteSize(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseByteSize(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseByteSize(%q) = %d, want %d", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshalByteSize(t *testing.T) {
+	type Config struct {
+		Memory  int64    `env:"MEMORY,unit=bytes"`
+		Disk    uint64   `env:"DISK,unit=bytes,default=10GiB"`
+		Buffers []uint32 `env:"BUFFERS,unit=bytes"`
+		Small   uint8    `env:"SMALL,unit=bytes"`
+	}
+
+	envs := map[string]string{
+		"MEMORY":  "512MiB",
+		"BUFFERS": "4KiB;64KB",
+	}
+	var cfg Config
+	if err := Unmarshal(envs, &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if cfg.Memory != 512<<20 || cfg.Disk != 10<<30 {
+		t.Errorf("Memory = %d, Disk = %d", cfg.Memory, cfg.Disk)
+	}
+	if len(cfg.Buffers) != 2 || cfg.Buffers[0] != 4096 || cfg.Buffers[1] != 64000 {
+		t.Errorf("Buffers = %v", cfg.Buffers)
+	}
+
+	if err := Unmarshal(map[string]string{"SMALL": "1KB"}, &cfg); err == nil {
+		t.Error("expected overflow error for uint8")
+	}
+}
+
+func TestUnmarshalBigNumbers(t *testing.T) {
+	type Config struct {
+		Supply  big.Int    `env:"SUPPLY"`
+		Wei     *big.Int   `env:"WEI"`
+		Price   *big.Float `env:"PRICE"`
+		Ratio   big.Rat    `env:"RATIO"`
+		Weights []*big.Int `env:"WEIGHTS"`
+	}
+
+	const supply = "115792089237316195423570985008687907853269984665640564039457584007913129639935"
+	envs := map[string]string{
+		"SUPPLY":  supply,
+		"WEI":     "0xde0b6b3a7640000",
+		"PRICE":   "1234.5678",
+		"RATIO":   "3/4",
+		"WEIGHTS": "1;18446744073709551616",
+	}
+	var cfg Config
+	if err := Unmarshal(envs, &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if cfg.Supply.String() != supply {
+		t.Errorf("Supply = %s", cfg.Supply.String())
+	}
+	if cfg.Wei == nil || cfg.Wei.String() != "1000000000000000000" {
+		t.Errorf("Wei = %v", cfg.Wei)
+	}
+	if cfg.Price == nil || cfg.Price.Text('f', 4) != "1234.5678" {
+		t.Errorf("Price = %v", cfg.Price)
+	}
+	if cfg.Ratio.String() != "3/4" {
+		t.Errorf("Ratio = %s", cfg.Ratio.String())
+	}
+	if len(cfg.Weights) != 2 || cfg.Weights[1].String() != "18446744073709551616" {
+		t.Errorf("Weights = %v", cfg.Weights)
+	}
+
+	out, err := Marshal(cfg)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if out["SUPPLY"] != supply || out["RATIO"] != "3/4" || out["WEI"] != "1000000000000000000" {
+		t.Errorf("Marshal() = %v", out)
+	}
+
+	if err := Unmarshal(map[string]string{"SUPPLY": "12abc"}, &cfg); err == nil {
+		t.Error("expected error for invalid big.Int")
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	type Upstream struct {
+		Name   string `json:"name"`
+		Weight int    `json:"weight"`
+	}
+
+	type Config struct {
+		Features  map[string]bool  `env:"FEATURES,json"`
+		Upstreams []Upstream       `env:"UPSTREAMS,json"`
+		Primary   Upstream         `env:"PRIMARY,json"`
+		Limits    *map[string]int  `env:"LIMITS,json"`
+		Defaults  map[string]int64 `env:"DEFAULTS,json,default={\"a\":1}"`
+	}
+
+	envs := map[string]string{
+		"FEATURES":  `{"beta": true, "dark_mode": false}`,
+		"UPSTREAMS": `[{"name": "a", "weight": 1}, {"name": "b", "weight": 3}]`,
+		"PRIMARY":   `{"name": "main", "weight": 10}`,
+		"LIMITS":    `{"rps": 100}`,
+	}
+	var cfg Config
+	if err := Unmarshal(envs, &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if !cfg.Features["beta"] || cfg.Features["dark_mode"] {
+		t.Errorf("Features = %v", cfg.Features)
+	}
+	if len(cfg.Upstreams) != 2 || cfg.Upstreams[1] != (Upstream{Name: "b", Weight: 3}) {
+		t.Errorf("Upstreams = %+v", cfg.Upstreams)
+	}
+	if cfg.Primary != (Upstream{Name: "main", Weight: 10}) {
+		t.Errorf("Primary = %+v", cfg.Primary)
+	}
+	if cfg.Limits == nil || (*cfg.Limits)["rps"] != 100 {
+		t.Errorf("Limits = %v", cfg.Limits)
+	}
+	if cfg.Defaults["a"] != 1 {
+		t.Errorf("Defaults = %v", cfg.Defaults)
+	}
+
+	err := Unmarshal(map[string]string{"UPSTREAMS": `[{"name": 1}]`}, &cfg)
+	if err == nil || !strings.Contains(err.Error(), "UPSTREAMS") {
+		t.Errorf("Unmarshal() error = %v, want invalid JSON for UPSTREAMS", err)
+	}
+}
+
+func TestUnmarshalStructSlice(t *testing.T) {
+	type Server struct {
+		Host string `env:"HOST,required"`
+		Port int    `env:"PORT,default=80"`
+	}
+
+	type Config struct {
+		Servers  []Server  `envPrefix:"SERVERS_"`
+		Replicas []*Server `envPrefix:"REPLICAS_"`
+		Unused   []Server  `envPrefix:"UNUSED_"`
+	}
+
+	envs := map[string]string{
+		"SERVERS_0_HOST":  "a.local",
+		"SERVERS_0_PORT":  "8080",
+		"SERVERS_1_HOST":  "b.local",
+		"SERVERS_3_HOST":  "ignored.local",
+		"REPLICAS_0_HOST": "replica.local",
+	}
+	var cfg Config
+	if err := Unmarshal(envs, &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	want := []Server{{Host: "a.local", Port: 8080}, {Host: "b.local", Port: 80}}
+	if !reflect.DeepEqual(cfg.Servers, want) {
+		t.Errorf("Servers = %+v, want %+v", cfg.Servers, want)
+	}
+	if len(cfg.Replicas) != 1 || cfg.Replicas[0].Host != "replica.local" {
+		t.Errorf("Replicas = %+v", cfg.Replicas)
+	}
+	if cfg.Unused != nil {
+		t.Errorf("Unused = %+v, want nil", cfg.Unused)
+	}
+
+	err := Unmarshal(map[string]string{"SERVERS_0_PORT": "1"}, &cfg)
+	if err == nil || !strings.Contains(err.Error(), "SERVERS_0_HOST") {
+		t.Errorf("Unmarshal() error = %v, want missing SERVERS_0_HOST", err)
+	}
+}
+
+func TestUnmarshalStructMap(t *testing.T) {
+	type Upstream struct {
+		URL     string        `env:"URL,required"`
+		Timeout time.Duration `env:"TIMEOUT,default=5s"`
+	}
+
+	type name string
+
+	type Config struct {
+		Upstreams map[string]Upstream `envPrefix:"UPSTREAMS_"`
+		Backends  map[name]*Upstream  `envPrefix:"BACKENDS_"`
+	}
+
+	envs := map[string]string{
+		"UPSTREAMS_API_URL":           "http://api",
+		"UPSTREAMS_API_TIMEOUT":       "1s",
+		"UPSTREAMS_AUTH_SERVICE_URL":  "http://auth",
+		"UPSTREAMS_UNRELATED":         "x",
+		"BACKENDS_PRIMARY_URL":        "http://primary",
+		"BACKENDS_PRIMARY_UNRELATED":  "x",
+		"UNPREFIXED_API_URL":          "http://other",
+		"UPSTREAMS_ORPHAN_TIMEOUT_MS": "10",
+	}
+	var cfg Config
+	if err := Unmarshal(envs, &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	want := map[string]Upstream{
+		"API":          {URL: "http://api", Timeout: time.Second},
+		"AUTH_SERVICE": {URL: "http://auth", Timeout: 5 * time.Second},
+	}
+	if !reflect.DeepEqual(cfg.Upstreams, want) {
+		t.Errorf("Upstreams = %+v, want %+v", cfg.Upstreams, want)
+	}
+	if len(cfg.Backends) != 1 || cfg.Backends["PRIMARY"] == nil || cfg.Backends["PRIMARY"].URL != "http://primary" {
+		t.Errorf("Backends = %+v", cfg.Backends)
+	}
+
+	err := Unmarshal(map[string]string{"UPSTREAMS_WEB_TIMEOUT": "1s"}, &cfg)
+	if err == nil || !strings.Contains(err.Error(), "UPSTREAMS_WEB_URL") {
+		t.Errorf("Unmarshal() error = %v, want missing UPSTREAMS_WEB_URL", err)
+	}
+}
+
+func TestUnmarshalStructMapOverlappingKeys(t *testing.T) {
+	type Upstream struct {
+		URL    string `env:"URL"`
+		APIURL string `env:"API_URL"`
+	}
+	type Config struct {
+		Upstreams map[string]Upstream `envPrefix:"UPS_"`
+	}
+
+	envs := map[string]string{
+		"UPS_WEB_URL":     "http://web",
+		"UPS_WEB_API_URL": "http://web/api",
+	}
+	var cfg Config
+	if err := Unmarshal(envs, &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	want := map[string]Upstream{"WEB": {URL: "http://web", APIURL: "http://web/api"}}
+	if !reflect.DeepEqual(cfg.Upstreams, want) {
+		t.Errorf("Upstreams = %+v, want %+v", cfg.Upstreams, want)
+	}
+}
+
+func TestUnmarshalMapKeyTypes(t *testing.T) {
+	type region string
+
+	type Config struct {
+		Ports    map[int]string           `env:"PORTS"`
+		Weights  map[uint8]float64        `env:"WEIGHTS"`
+		Regions  map[region]int           `env:"REGIONS,lowerkeys"`
+		Flags    map[bool]string          `env:"FLAGS"`
+		Levels   map[testLevel]bool       `env:"LEVELS"`
+		Timeouts map[time.Duration]string `env:"TIMEOUTS"`
+	}
+
+	envs := map[string]string{
+		"PORTS":    "80:http;443:https",
+		"WEIGHTS":  "1:0.5;2:1.5",
+		"REGIONS":  "EU:1;US:2",
+		"FLAGS":    "true:on;false:off",
+		"LEVELS":   "debug:true",
+		"TIMEOUTS": "1s:fast;1m:slow",
+	}
+	var cfg Config
+	if err := Unmarshal(envs, &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if cfg.Ports[443] != "https" || cfg.Weights[2] != 1.5 || cfg.Regions["us"] != 2 {
+		t.Errorf("Ports = %v, Weights = %v, Regions = %v", cfg.Ports, cfg.Weights, cfg.Regions)
+	}
+	if cfg.Flags[false] != "off" || !cfg.Levels[1] || cfg.Timeouts[time.Minute] != "slow" {
+		t.Errorf("Flags = %v, Levels = %v, Timeouts = %v", cfg.Flags, cfg.Levels, cfg.Timeouts)
+	}
+
+	err := Unmarshal(map[string]string{"PORTS": "http:80"}, &cfg)
+	if err == nil || !strings.Contains(err.Error(), `invalid map key "http"`) {
+		t.Errorf("Unmarshal() error = %v, want invalid map key", err)
+	}
+}
+
+func TestUnmarshalMapKVSep(t *testing.T) {
+	type Config struct {
+		Labels    map[string]string `env:"LABELS,kvsep=="`
+		Endpoints map[string]string `env:"ENDPOINTS,kvsep=@"`
+		Colons    map[string]string `env:"COLONS"`
+	}
+
+	envs := map[string]string{
+		"LABELS":    "a=1;b=2",
+		"ENDPOINTS": "api@http://api:8080;db@[::1]:5432",
+		"COLONS":    "k:v:w",
+	}
+	var cfg Config
+	if err := Unmarshal(envs, &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if cfg.Labels["a"] != "1" || cfg.Labels["b"] != "2" {
+		t.Errorf("Labels = %v", cfg.Labels)
+	}
+	if cfg.Endpoints["api"] != "http://api:8080" || cfg.Endpoints["db"] != "[::1]:5432" {
+		t.Errorf("Endpoints = %v", cfg.Endpoints)
+	}
+	if cfg.Colons["k"] != "v:w" {
+		t.Errorf("Colons = %v", cfg.Colons)
+	}
+}
+
+func TestUnmarshalMapKeyValueSeparatorGlobal(t *testing.T) {
+	defer func() { KeyValueSeparator = ":" }()
+	KeyValueSeparator = "="
+
+	type Config struct {
+		Labels map[string]string `env:"LABELS"`
+	}
+
+	var cfg Config
+	if err := Unmarshal(map[string]string{"LABELS": "a=1;url=http://x"}, &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if cfg.Labels["a"] != "1" || cfg.Labels["url"] != "http://x" {
+		t.Errorf("Labels = %v", cfg.Labels)
+	}
+
+	envs, err := Marshal(cfg)
+	if err != nil || envs["LABELS"] != "a=1;url=http://x" {
+		t.Errorf("Marshal() = %v, %v", envs, err)
+	}
+}
+
+func TestUnmarshalMapOfSlices(t *testing.T) {
+	type Config struct {
+		Headers map[string][]string `env:"HEADERS,innersep=|"`
+		Ports   map[string][]int    `env:"PORTS,innersep=\\,"`
+		Whole   map[string][]string `env:"WHOLE"`
+	}
+
+	envs := map[string]string{
+		"HEADERS": "Accept:json|xml;X-Env:prod",
+		"PORTS":   "web:80,443;db:5432",
+		"WHOLE":   "a:x|y",
+	}
+	var cfg Config
+	if err := Unmarshal(envs, &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	want := map[string][]string{"Accept": {"json", "xml"}, "X-Env": {"prod"}}
+	if !reflect.DeepEqual(cfg.Headers, want) {
+		t.Errorf("Headers = %v, want %v", cfg.Headers, want)
+	}
+	if !reflect.DeepEqual(cfg.Ports, map[string][]int{"web": {80, 443}, "db": {5432}}) {
+		t.Errorf("Ports = %v", cfg.Ports)
+	}
+	if !reflect.DeepEqual(cfg.Whole, map[string][]string{"a": {"x|y"}}) {
+		t.Errorf("Whole = %v", cfg.Whole)
+	}
+
+	out, err := Marshal(cfg)
+	if err != nil || out["HEADERS"] != "Accept:json|xml;X-Env:prod" {
+		t.Errorf("Marshal() = %v, %v", out, err)
+	}
+}
+
+func TestTagFieldValidate(t *testing.T) {
+	tests := []struct {
+		tag     string
+		typ     reflect.Type
+		wantErr bool
+	}{
+		{"T,layout=2006-01-02", reflect.TypeFor[time.Time](), false},
+		{"T,layout=2006-01-02", reflect.TypeFor[[]time.Time](), false},
+		{"T,layout=2006-01-02", reflect.TypeFor[string](), true},
+		{"T,schemes=https", reflect.TypeFor[*url.URL](), false},
+		{"T,schemes=https", reflect.TypeFor[[]*url.URL](), false},
+		{"T,schemes=https", reflect.TypeFor[string](), true},
+		{"T,encoding=hex", reflect.TypeFor[[]byte](), false},
+		{"T,encoding=hex", reflect.TypeFor[[][]byte](), false},
+		{"T,encoding=hex", reflect.TypeFor[[]int](), true},
+		{"T,base64", reflect.TypeFor[string](), false},
+		{"T,base64", reflect.TypeFor[[]string](), false},
+		{"T,base64", reflect.TypeFor[int](), true},
+		{"T,min=1,max=2", reflect.TypeFor[*uint](), false},
+		{"T,max=1s", reflect.TypeFor[time.Duration](), false},
+		{"T,min=1", reflect.TypeFor[string](), true},
+		{"T,pattern=^a+$", reflect.TypeFor[string](), false},
+		{"T,pattern=(", reflect.TypeFor[string](), true},
+		{"T,unit=bytes", reflect.TypeFor[uint64](), false},
+		{"T,unit=bytes", reflect.TypeFor[[]int](), false},
+		{"T,unit=bytes", reflect.TypeFor[time.Duration](), true},
+		{"T,kvsep==", reflect.TypeFor[map[string]int](), false},
+		{"T,kvsep==", reflect.TypeFor[[]string](), true},
+		{"T,innersep=|", reflect.TypeFor[map[string][]string](), false},
+		{"T,innersep=|", reflect.TypeFor[map[string]string](), true},
+		{"T,innersep=|", reflect.TypeFor[[][]int](), false},
+		{"T,innersep=|", reflect.TypeFor[[]int](), true},
+		{"T,trim", reflect.TypeFor[[2]string](), false},
+		{"T,trim", reflect.TypeFor[string](), true},
+		{"T,omitempty", reflect.TypeFor[map[string]int](), false},
+		{"T,omitempty", reflect.TypeFor[int](), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tag+" "+tt.typ.String(), func(t *testing.T) {
+			err := parseTag(tt.tag).validate(tt.typ)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidTagOption) {
+				t.Errorf("validate() error = %v, want ErrInvalidTagOption", err)
+			}
+		})
+	}
+}
+
+func TestUnmarshalNestedSlices(t *testing.T) {
+	type Config struct {
+		Matrix [][]int          `env:"MATRIX,innersep=\\,"`
+		Groups [][]string       `env:"GROUPS,separator=|,innersep=+"`
+		Ranges []map[string]int `env:"RANGES,innersep=\\,"`
+	}
+
+	envs := map[string]string{
+		"MATRIX": "1,2;3,4",
+		"GROUPS": "a+b|c",
+		"RANGES": "min:1,max:5;min:10,max:20",
+	}
+	var cfg Config
+	if err := Unmarshal(envs, &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(cfg.Matrix, [][]int{{1, 2}, {3, 4}}) {
+		t.Errorf("Matrix = %v", cfg.Matrix)
+	}
+	if !reflect.DeepEqual(cfg.Groups, [][]string{{"a", "b"}, {"c"}}) {
+		t.Errorf("Groups = %v", cfg.Groups)
+	}
+	if len(cfg.Ranges) != 2 || cfg.Ranges[1]["max"] != 20 {
+		t.Errorf("Ranges = %v", cfg.Ranges)
+	}
+
+	out, err := Marshal(cfg)
+	if err != nil || out["MATRIX"] != "1,2;3,4" || out["GROUPS"] != "a+b|c" {
+		t.Errorf("Marshal() = %v, %v", out, err)
+	}
+
+	if err := Unmarshal(map[string]string{"MATRIX": "1,x;3"}, &cfg); err == nil {
+		t.Error("expected error for invalid matrix element")
+	}
+}
+
+func TestUnmarshalArray(t *testing.T) {
+	type Config struct {
+		RGB    [3]uint8    `env:"RGB,separator=\\,"`
+		Pair   [2]string   `env:"PAIR"`
+		Bounds [2][2]int   `env:"BOUNDS,innersep=\\,"`
+		Ptr    *[2]float64 `env:"PTR"`
+	}
+
+	envs := map[string]string{
+		"RGB":    "255,128,0",
+		"PAIR":   "a;b",
+		"BOUNDS": "0,0;10,20",
+		"PTR":    "1.5;2.5",
+	}
+	var cfg Config
+	if err := Unmarshal(envs, &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if cfg.RGB != [3]uint8{255, 128, 0} || cfg.Pair != [2]string{"a", "b"} {
+		t.Errorf("RGB = %v, Pair = %v", cfg.RGB, cfg.Pair)
+	}
+	if cfg.Bounds != [2][2]int{{0, 0}, {10, 20}} || cfg.Ptr == nil || *cfg.Ptr != [2]float64{1.5, 2.5} {
+		t.Errorf("Bounds = %v, Ptr = %v", cfg.Bounds, cfg.Ptr)
+	}
+
+	out, err := Marshal(cfg)
+	if err != nil || out["RGB"] != "255,128,0" || out["BOUNDS"] != "0,0;10,20" {
+		t.Errorf("Marshal() = %v, %v", out, err)
+	}
+
+	err = Unmarshal(map[string]string{"RGB": "255,128"}, &cfg)
+	if err == nil || !strings.Contains(err.Error(), "expected 3 elements for [3]uint8, got 2") {
+		t.Errorf("Unmarshal() error = %v, want element count error", err)
+	}
+}
+
+func TestUnmarshalTrim(t *testing.T) {
+	type Config struct {
+		Names  []string          `env:"NAMES,separator=\\,,trim"`
+		Ports  []int             `env:"PORTS,separator=\\,,trim"`
+		Pair   [2]int            `env:"PAIR,trim"`
+		Labels map[string]string `env:"LABELS,trim"`
+		Raw    []string          `env:"RAW,separator=\\,"`
+	}
+
+	envs := map[string]string{
+		"NAMES":  "a, b ,c",
+		"PORTS":  "80, 443",
+		"PAIR":   " 1 ; 2 ",
+		"LABELS": "env : prod ; region: us",
+		"RAW":    "a, b",
+	}
+	var cfg Config
+	if err := Unmarshal(envs, &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(cfg.Names, []string{"a", "b", "c"}) || !reflect.DeepEqual(cfg.Ports, []int{80, 443}) {
+		t.Errorf("Names = %q, Ports = %v", cfg.Names, cfg.Ports)
+	}
+	if cfg.Pair != [2]int{1, 2} || cfg.Labels["env"] != "prod" || cfg.Labels["region"] != "us" {
+		t.Errorf("Pair = %v, Labels = %q", cfg.Pair, cfg.Labels)
+	}
+	if !reflect.DeepEqual(cfg.Raw, []string{"a", " b"}) {
+		t.Errorf("Raw = %q, want untrimmed elements", cfg.Raw)
+	}
+}
+
+func TestUnmarshalTrimElementsGlobal(t *testing.T) {
+	defer func() { TrimElements = false }()
+	TrimElements = true
+
+	type Config struct {
+		Ports []int  `env:"PORTS"`
+		Host  string `env:"HOST"`
+	}
+
+	var cfg Config
+	if err := Unmarshal(map[string]string{"PORTS": "80; 443", "HOST": " spaced "}, &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if !reflect.DeepEqual(cfg.Ports, []int{80, 443}) || cfg.Host != " spaced " {
+		t.Errorf("Ports = %v, Host = %q", cfg.Ports, cfg.Host)
+	}
+}
+
+func TestUnmarshalOmitEmpty(t *testing.T) {
+	type Config struct {
+		Names  []string          `env:"NAMES,omitempty"`
+		Ports  []int             `env:"PORTS,omitempty,trim"`
+		Pair   [2]int            `env:"PAIR,omitempty"`
+		Labels map[string]string `env:"LABELS,omitempty"`
+		Empty  []int             `env:"EMPTY,omitempty"`
+		Kept   []string          `env:"KEPT"`
+	}
+
+	envs := map[string]string{
+		"NAMES":  "a;;b;",
+		"PORTS":  ";80; ;443;",
+		"PAIR":   "1;;2",
+		"LABELS": "a:1;;b:2;",
+		"EMPTY":  "",
+		"KEPT":   "a;;b",
+	}
+	var cfg Config
+	if err := Unmarshal(envs, &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(cfg.Names, []string{"a", "b"}) || !reflect.DeepEqual(cfg.Ports, []int{80, 443}) {
+		t.Errorf("Names = %q, Ports = %v", cfg.Names, cfg.Ports)
+	}
+	if cfg.Pair != [2]int{1, 2} || len(cfg.Labels) != 2 {
+		t.Errorf("Pair = %v, Labels = %v", cfg.Pair, cfg.Labels)
+	}
+	if cfg.Empty == nil || len(cfg.Empty) != 0 {
+		t.Errorf("Empty = %#v, want empty slice", cfg.Empty)
+	}
+	if !reflect.DeepEqual(cfg.Kept, []string{"a", "", "b"}) {
+		t.Errorf("Kept = %q, want empty element kept", cfg.Kept)
+	}
+}
+
+func TestUnmarshalComplex(t *testing.T) {
+	type Config struct {
+		Gain   complex128   `env:"GAIN"`
+		Pole   complex64    `env:"POLE"`
+		Zeros  []complex128 `env:"ZEROS"`
+		Scalar *complex128  `env:"SCALAR"`
+	}
+
+	envs := map[string]string{
+		"GAIN":   "1.5+2i",
+		"POLE":   "(-0.5-1e-3i)",
+		"ZEROS":  "1i;-2",
+		"SCALAR": "3",
+	}
+	var cfg Config
+	if err := Unmarshal(envs, &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if cfg.Gain != complex(1.5, 2) || cfg.Pole != complex64(complex(-0.5, -1e-3)) {
+		t.Errorf("Gain = %v, Pole = %v", cfg.Gain, cfg.Pole)
+	}
+	if !reflect.DeepEqual(cfg.Zeros, []complex128{1i, -2}) || cfg.Scalar == nil || *cfg.Scalar != 3 {
+		t.Errorf("Zeros = %v, Scalar = %v", cfg.Zeros, cfg.Scalar)
+	}
+
+	out, err := Marshal(cfg)
+	if err != nil || out["GAIN"] != "(1.5+2i)" {
+		t.Errorf("Marshal() = %v, %v", out, err)
+	}
+
+	if err := Unmarshal(map[string]string{"GAIN": "1+2j"}, &cfg); err == nil {
+		t.Error("expected error for invalid complex number")
+	}
+}
+
+func TestUnmarshalIntegerLiterals(t *testing.T) {
+	type Config struct {
+		Mask   int    `env:"MASK"`
+		Mode   uint32 `env:"MODE"`
+		Flags  uint8  `env:"FLAGS"`
+		Offset int64  `env:"OFFSET"`
+		Padded int    `env:"PADDED"`
+		Big    uint64 `env:"BIG"`
+	}
+
+	envs := map[string]string{
+		"MASK":   "0x1F",
+		"MODE":   "0o755",
+		"FLAGS":  "0b1010",
+		"OFFSET": "-0x10",
+		"PADDED": "010",
+		"BIG":    "0xFFFF_FFFF",
+	}
+	var cfg Config
+	if err := Unmarshal(envs, &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	want := Config{Mask: 31, Mode: 0o755, Flags: 10, Offset: -16, Padded: 10, Big: 0xFFFFFFFF}
+	if cfg != want {
+		t.Errorf("Unmarshal() = %+v, want %+v", cfg, want)
+	}
+
+	if err := Unmarshal(map[string]string{"MASK": "0xZZ"}, &cfg); err == nil {
+		t.Error("expected error for invalid hex literal")
+	}
+}
+
+func TestUnmarshalLenientBools(t *testing.T) {
+	defer func() { LenientBools = false }()
+
+	type Config struct {
+		Debug   bool   `env:"DEBUG"`
+		Feature bool   `env:"FEATURE"`
+		Cache   *bool  `env:"CACHE"`
+		Flags   []bool `env:"FLAGS"`
+	}
+	envs := map[string]string{"DEBUG": "on", "FEATURE": "Yes", "CACHE": "DISABLED", "FLAGS": "y;n;true"}
+
+	var cfg Config
+	if err := Unmarshal(maps.Clone(envs), &cfg); err == nil {
+		t.Error("expected error for extended bool forms without LenientBools")
+	}
+
+	LenientBools = true
+	cfg = Config{}
+	if err := Unmarshal(maps.Clone(envs), &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if !cfg.Debug || !cfg.Feature || cfg.Cache == nil || *cfg.Cache || !reflect.DeepEqual(cfg.Flags, []bool{true, false, true}) {
+		t.Errorf("Unmarshal() = %+v", cfg)
+	}
+
+	if err := Unmarshal(map[string]string{"DEBUG": "maybe"}, &cfg); err == nil {
+		t.Error("expected error for unknown bool form")
+	}
+}
+
+func TestUnmarshalIntegerOverflow(t *testing.T) {
+	type Config struct {
+		Int8   int8    `env:"INT8"`
+		Int16  int16   `env:"INT16"`
+		Uint8  uint8   `env:"UINT8"`
+		Uint16 uint16  `env:"UINT16"`
+		Int32s []int32 `env:"INT32S"`
+	}
+
+	valid := map[string]string{"INT8": "-128", "INT16": "32767", "UINT8": "0xFF", "UINT16": "65535", "INT32S": "2147483647"}
+	var cfg Config
+	if err := Unmarshal(valid, &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if cfg.Int8 != -128 || cfg.Uint8 != 255 || cfg.Int32s[0] != 2147483647 {
+		t.Errorf("Unmarshal() = %+v", cfg)
+	}
+
+	overflow := map[string]string{"INT8": "128", "INT16": "99999999999", "UINT8": "256", "UINT16": "0x10000", "INT32S": "1;2147483648"}
+	for key, value := range overflow {
+		cfg = Config{}
+		err := Unmarshal(map[string]string{key: value}, &cfg)
+		if !errors.Is(err, strconv.ErrRange) {
+			t.Errorf("%s=%s error = %v, want strconv.ErrRange", key, value, err)
+		}
+	}
+}
+
+func TestUnmarshalDurationCollections(t *testing.T) {
+	type Config struct {
+		Backoffs []time.Duration          `env:"RETRY_BACKOFFS"`
+		Timeouts map[string]time.Duration `env:"TIMEOUTS"`
+		Delays   []time.Duration          `env:"DELAYS,unit=ms"`
+		Window   [2]time.Duration         `env:"WINDOW"`
+	}
+
+	envs := map[string]string{
+		"RETRY_BACKOFFS": "1s;5s;30s",
+		"TIMEOUTS":       "read:500ms;write:2s",
+		"DELAYS":         "100;1.5s",
+		"WINDOW":         "1m;1h",
+	}
+	var cfg Config
+	if err := Unmarshal(envs, &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(cfg.Backoffs, []time.Duration{time.Second, 5 * time.Second, 30 * time.Second}) {
+		t.Errorf("Backoffs = %v", cfg.Backoffs)
+	}
+	if cfg.Timeouts["read"] != 500*time.Millisecond || cfg.Timeouts["write"] != 2*time.Second {
+		t.Errorf("Timeouts = %v", cfg.Timeouts)
+	}
+	if !reflect.DeepEqual(cfg.Delays, []time.Duration{100 * time.Millisecond, 1500 * time.Millisecond}) {
+		t.Errorf("Delays = %v", cfg.Delays)
+	}
+	if cfg.Window != [2]time.Duration{time.Minute, time.Hour} {
+		t.Errorf("Window = %v", cfg.Window)
+	}
+
+	out, err := Marshal(cfg)
+	if err != nil || out["RETRY_BACKOFFS"] != "1s;5s;30s" || out["TIMEOUTS"] != "read:500ms;write:2s" {
+		t.Errorf("Marshal() = %v, %v", out, err)
+	}
+
+	if err := Unmarshal(map[string]string{"RETRY_BACKOFFS": "1s;soon"}, &cfg); err == nil {
+		t.Error("expected error for invalid duration element")
+	}
+}
+
+func TestUnmarshalAliasKeys(t *testing.T) {
+	type Inner struct {
+		Host string `env:"HOST|HOSTNAME"`
+	}
+
+	type Config struct {
+		URL   string `env:"DATABASE_URL|DB_URL|POSTGRES_URL,required"`
+		Port  int    `env:"PORT|LEGACY_PORT,default=8080"`
+		Inner Inner  `envPrefix:"APP_"`
+	}
+
+	envs := map[string]string{
+		"DB_URL":       "postgres://old",
+		"POSTGRES_URL": "postgres://older",
+		"APP_HOSTNAME": "legacy.local",
+	}
+	var cfg Config
+	if err := Unmarshal(envs, &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if cfg.URL != "postgres://old" || cfg.Port != 8080 || cfg.Inner.Host != "legacy.local" {
+		t.Errorf("Unmarshal() = %+v", cfg)
+	}
+	r, _ := UnmarshalWithReport(envs, &Config{})
+	if !slices.Contains(r.Consumed, "DB_URL") {
+		t.Error("DB_URL should be consumed")
+	}
+	if !slices.Contains(r.Remaining, "POSTGRES_URL") {
+		t.Error("POSTGRES_URL should not be consumed")
+	}
+
+	cfg = Config{}
+	if err := Unmarshal(map[string]string{"DATABASE_URL": "new", "DB_URL": "old"}, &cfg); err != nil || cfg.URL != "new" {
+		t.Errorf("Unmarshal() URL = %q, %v, want primary key to win", cfg.URL, err)
+	}
+
+	err := Unmarshal(map[string]string{}, &cfg)
+	if err == nil || !strings.Contains(err.Error(), "DATABASE_URL: required field not found") {
+		t.Errorf("Unmarshal() error = %v, want missing DATABASE_URL", err)
+	}
+}
+
+func TestWarningHookDeprecated(t *testing.T) {
+	defer SetWarningHook(nil)
+
+	type Config struct {
+		Host    string `env:"HOST"`
+		Timeout int    `env:"TIMEOUT_SECS|TIMEOUT,deprecated=use TIMEOUT_SECONDS"`
+		Legacy  bool   `env:"LEGACY_MODE,deprecated=no longer has any effect\\, remove it"`
+		Unused  string `env:"UNUSED,deprecated=gone,default=x"`
+	}
+
+	var warnings []string
+	SetWarningHook(func(key, message string) {
+		warnings = append(warnings, key+": "+message)
+	})
+
+	envs := map[string]string{"HOST": "localhost", "TIMEOUT": "30", "LEGACY_MODE": "true"}
+	var cfg Config
+	if err := Unmarshal(envs, &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if cfg.Timeout != 30 || !cfg.Legacy {
+		t.Errorf("Unmarshal() = %+v, want deprecated fields populated", cfg)
+	}
+	want := []string{"TIMEOUT: use TIMEOUT_SECONDS", "LEGACY_MODE: no longer has any effect, remove it"}
+	if !reflect.DeepEqual(warnings, want) {
+		t.Errorf("warnings = %q, want %q", warnings, want)
+	}
+}
+
+func TestUnmarshalNotEmpty(t *testing.T) {
+	type Config struct {
+		Token string   `env:"TOKEN,required,notEmpty"`
+		Name  string   `env:"NAME,notEmpty,default=app"`
+		Tags  []string `env:"TAGS,notEmpty"`
+		Note  string   `env:"NOTE,required"`
+	}
+
+	tests := []struct {
+		name    string
+		envs    map[string]string
+		wantErr string
+	}{
+		{"all set", map[string]string{"TOKEN": "t", "TAGS": "a", "NOTE": ""}, ""},
+		{"empty default applies", map[string]string{"TOKEN": "t", "NAME": "", "TAGS": "a", "NOTE": ""}, ""},
+		{"empty required", map[string]string{"TOKEN": "", "TAGS": "a", "NOTE": ""}, "TOKEN: must not be empty"},
+		{"empty optional", map[string]string{"TOKEN": "t", "TAGS": "", "NOTE": ""}, "TAGS: must not be empty"},
+		{"missing", map[string]string{"TOKEN": "t", "NOTE": ""}, "TAGS: required field not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg Config
+			err := Unmarshal(tt.envs, &cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Unmarshal() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Unmarshal() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUnmarshalUnset(t *testing.T) {
+	t.Setenv("UNSET_SECRET", "s3cr3t")
+	t.Setenv("UNSET_BAD", "not-a-number")
+	t.Setenv("UNSET_KEPT", "visible")
+
+	type Config struct {
+		Secret string `env:"UNSET_SECRET,unset"`
+		Kept   string `env:"UNSET_KEPT"`
+	}
+
+	var cfg Config
+	if err := UnmarshalFromEnv(&cfg); err != nil {
+		t.Fatalf("UnmarshalFromEnv() error = %v", err)
+	}
+
+	if cfg.Secret != "s3cr3t" || cfg.Kept != "visible" {
+		t.Errorf("UnmarshalFromEnv() = %+v", cfg)
+	}
+	if _, ok := os.LookupEnv("UNSET_SECRET"); ok {
+		t.Error("UNSET_SECRET should be removed from the environment")
+	}
+	if _, ok := os.LookupEnv("UNSET_KEPT"); !ok {
+		t.Error("UNSET_KEPT should stay in the environment")
+	}
+
+	var bad struct {
+		Port int `env:"UNSET_BAD,unset"`
+	}
+	if err := UnmarshalFromEnv(&bad); err == nil {
+		t.Error("expected error for invalid UNSET_BAD")
+	}
+	if _, ok := os.LookupEnv("UNSET_BAD"); ok {
+		t.Error("UNSET_BAD should be removed even when parsing fails")
+	}
+}
+
+func TestUnmarshalExpand(t *testing.T) {
+	type Config struct {
+		ConfigPath string   `env:"CONFIG_PATH,expand"`
+		CacheDir   string   `env:"CACHE_DIR,expand,default=${HOME}/.cache"`
+		Raw        string   `env:"RAW"`
+		Paths      []string `env:"PATHS,expand"`
+	}
+
+	envs := map[string]string{
+		"HOME":        "/home/app",
+		"APP":         "svc",
+		"CONFIG_PATH": "$HOME/${APP}/config.yaml",
+		"RAW":         "$HOME",
+		"PATHS":       "$HOME/a;${MISSING}b",
+	}
+	var cfg Config
+	if err := Unmarshal(envs, &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if cfg.ConfigPath != "/home/app/svc/config.yaml" || cfg.CacheDir != "/home/app/.cache" {
+		t.Errorf("ConfigPath = %q, CacheDir = %q", cfg.ConfigPath, cfg.CacheDir)
+	}
+	if cfg.Raw != "$HOME" {
+		t.Errorf("Raw = %q, want unexpanded", cfg.Raw)
+	}
+	if !reflect.DeepEqual(cfg.Paths, []string{"/home/app/a", "b"}) {
+		t.Errorf("Paths = %q", cfg.Paths)
+	}
+}
+
+func TestUnmarshalFile(t *testing.T) {
+	dir := t.TempDir()
+	password := dir + "/password"
+	token := dir + "/token"
+	if err := os.WriteFile(password, []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(token, []byte("  abc  \n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	type Config struct {
+		Password string `env:"DB_PASSWORD,file"`
+		Token    string `env:"API_TOKEN,file"`
+		Port     int    `env:"PORT,file"`
+	}
+
+	t.Run("path and _FILE convention", func(t *testing.T) {
+		portFile := dir + "/port"
+		if err := os.WriteFile(portFile, []byte("8080\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		var cfg Config
+		envs := map[string]string{
+			"DB_PASSWORD":    password,
+			"API_TOKEN_FILE": token,
+			"PORT":           portFile,
+		}
+		if err := Unmarshal(envs, &cfg); err != nil {
+			t.Fatalf("Unmarshal() error = %v", err)
+		}
+		if cfg.Password != "s3cret" || cfg.Token != "abc" || cfg.Port != 8080 {
+			t.Errorf("cfg = %+v", cfg)
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		var cfg Config
+		err := Unmarshal(map[string]string{"DB_PASSWORD": dir + "/missing"}, &cfg)
+		if err == nil || !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("Unmarshal() error = %v, want os.ErrNotExist", err)
+		}
+	})
+}
+
+func TestUnmarshalSkipField(t *testing.T) {
+	type Cache struct {
+		Size int `env:"SIZE"`
+	}
+
+	type Config struct {
+		Host  string `env:"HOST"`
+		Cache Cache  `env:"-"`
+		Token string `env:"-"`
+	}
+
+	envs := map[string]string{"HOST": "localhost", "SIZE": "10", "-": "x"}
+	cfg := Config{Cache: Cache{Size: 1}, Token: "computed"}
+	if err := Unmarshal(envs, &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	want := Config{Host: "localhost", Cache: Cache{Size: 1}, Token: "computed"}
+	if cfg != want {
+		t.Errorf("Unmarshal() = %+v, want %+v", cfg, want)
+	}
+}
+
+func TestUnmarshalRequiredIf(t *testing.T) {
+	type Config struct {
+		TLS      bool   `env:"TLS"`
+		CertFile string `env:"CERT_FILE,required_if=TLS=true|1"`
+		Mode     string `env:"MODE"`
+		Password string `env:"PASSWORD,required_unless=MODE=dev"`
+		Token    string `env:"TOKEN,required_if=AUTH_URL"`
+	}
+
+	tests := []struct {
+		name    string
+		envs    map[string]string
+		wantErr string
+	}{
+		{"conditions not met", map[string]string{"MODE": "dev"}, ""},
+		{"required_if met", map[string]string{"MODE": "dev", "TLS": "true"}, "CERT_FILE"},
+		{"required_if other value", map[string]string{"MODE": "dev", "TLS": "1"}, "CERT_FILE"},
+		{"required_if satisfied", map[string]string{"MODE": "dev", "TLS": "true", "CERT_FILE": "/tls.crt"}, ""},
+		{"required_unless unmet", map[string]string{"MODE": "prod"}, "PASSWORD"},
+		{"required_unless missing key", map[string]string{}, "PASSWORD"},
+		{"required_if presence", map[string]string{"MODE": "dev", "AUTH_URL": "http://auth"}, "TOKEN"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg Config
+			err := Unmarshal(tt.envs, &cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Unmarshal() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr+": required field not found") {
+				t.Errorf("Unmarshal() error = %v, want missing %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUnmarshalExactlyOneOf(t *testing.T) {
+	type Config struct {
+		URL    string `env:"DATABASE_URL,exactly_one_of=DB_HOST|DB_SOCKET"`
+		Host   string `env:"DB_HOST"`
+		Port   int    `env:"DB_PORT,default=5432"`
+		Socket string `env:"DB_SOCKET"`
+	}
+
+	tests := []struct {
+		name    string
+		envs    map[string]string
+		wantErr string
+	}{
+		{"url", map[string]string{"DATABASE_URL": "postgres://db"}, ""},
+		{"host and port", map[string]string{"DB_HOST": "db", "DB_PORT": "5433"}, ""},
+		{"none", map[string]string{"DB_PORT": "5433"}, "exactly one of DATABASE_URL, DB_HOST, DB_SOCKET must be set"},
+		{"both", map[string]string{"DATABASE_URL": "postgres://db", "DB_HOST": "db"}, "got DATABASE_URL, DB_HOST"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg Config
+			err := Unmarshal(tt.envs, &cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Unmarshal() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Unmarshal() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUnmarshalRange(t *testing.T) {
+	type Config struct {
+		Port    int           `env:"PORT,min=1,max=65535"`
+		Ratio   float64       `env:"RATIO,min=0,max=1"`
+		Timeout time.Duration `env:"TIMEOUT,min=1s,max=1m"`
+		Workers *uint         `env:"WORKERS,min=1"`
+		Memory  int64         `env:"MEMORY,unit=bytes,max=1GiB"`
+	}
+
+	tests := []struct {
+		name    string
+		envs    map[string]string
+		wantErr string
+	}{
+		{"within range", map[string]string{"PORT": "8080", "RATIO": "0.5", "TIMEOUT": "30s", "WORKERS": "4", "MEMORY": "512MiB"}, ""},
+		{"bounds inclusive", map[string]string{"PORT": "65535", "RATIO": "0", "TIMEOUT": "1m"}, ""},
+		{"int below", map[string]string{"PORT": "0"}, "PORT: must be at least 1, got 0"},
+		{"int above", map[string]string{"PORT": "70000"}, "PORT: must be at most 65535, got 70000"},
+		{"float above", map[string]string{"RATIO": "1.5"}, "RATIO: must be at most 1"},
+		{"duration below", map[string]string{"TIMEOUT": "500ms"}, "TIMEOUT: must be at least 1s, got 500ms"},
+		{"pointer below", map[string]string{"WORKERS": "0"}, "WORKERS: must be at least 1"},
+		{"bytes above", map[string]string{"MEMORY": "2GiB"}, "MEMORY: must be at most 1GiB"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg Config
+			err := Unmarshal(tt.envs, &cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Unmarshal() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Unmarshal() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("invalid bound", func(t *testing.T) {
+		var cfg struct {
+			Port int `env:"PORT,min=one"`
+		}
+		if err := Unmarshal(map[string]string{"PORT": "1"}, &cfg); err == nil || !strings.Contains(err.Error(), "PORT: invalid min") {
+			t.Errorf("Unmarshal() error = %v", err)
+		}
+	})
+}
+
+func TestUnmarshalOneOf(t *testing.T) {
+	type Config struct {
+		Level    string   `env:"LOG_LEVEL,oneof=debug|info|warn|error,default=info"`
+		Features []string `env:"FEATURES,oneof=a|b|c"`
+	}
+
+	var cfg Config
+	if err := Unmarshal(map[string]string{"FEATURES": "a;c"}, &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if cfg.Level != "info" || !reflect.DeepEqual(cfg.Features, []string{"a", "c"}) {
+		t.Errorf("cfg = %+v", cfg)
+	}
+
+	err := Unmarshal(map[string]string{"LOG_LEVEL": "verbose"}, &cfg)
+	if err == nil || !strings.Contains(err.Error(), `LOG_LEVEL: must be one of debug, info, warn, error, got "verbose"`) {
+		t.Errorf("Unmarshal() error = %v", err)
+	}
+
+	err = Unmarshal(map[string]string{"FEATURES": "a;d"}, &cfg)
+	if err == nil || !strings.Contains(err.Error(), `got "d"`) {
+		t.Errorf("Unmarshal() error = %v", err)
+	}
+}
+
+func TestUnmarshalPattern(t *testing.T) {
+	type Config struct {
+		Tenant string   `env:"TENANT,pattern=^[a-z0-9-]+$"`
+		Codes  []string `env:"CODES,pattern=^[A-Z]{2\\,3}$"`
+	}
+
+	var cfg Config
+	if err := Unmarshal(map[string]string{"TENANT": "acme-1", "CODES": "US;GBR"}, &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if cfg.Tenant != "acme-1" || !reflect.DeepEqual(cfg.Codes, []string{"US", "GBR"}) {
+		t.Errorf("cfg = %+v", cfg)
+	}
+
+	err := Unmarshal(map[string]string{"TENANT": "Acme_1"}, &cfg)
+	if err == nil || !strings.Contains(err.Error(), `TENANT: must match ^[a-z0-9-]+$, got "Acme_1"`) {
+		t.Errorf("Unmarshal() error = %v", err)
+	}
+
+	err = Unmarshal(map[string]string{"CODES": "US;U"}, &cfg)
+	if err == nil || !strings.Contains(err.Error(), `got "U"`) {
+		t.Errorf("Unmarshal() error = %v", err)
+	}
+
+	var bad struct {
+		Name string `env:"NAME,pattern=["`
+	}
+	if err := Unmarshal(map[string]string{"NAME": "x"}, &bad); err == nil || !strings.Contains(err.Error(), "NAME: invalid pattern") {
+		t.Errorf("Unmarshal() error = %v", err)
+	}
+}
+
+func TestScreamingSnake(t *testing.T) {
+	tests := map[string]string{
+		"Port":        "PORT",
+		"MaxConns":    "MAX_CONNS",
+		"DBHost":      "DB_HOST",
+		"APIKey":      "API_KEY",
+		"HTTPServer2": "HTTP_SERVER2",
+		"ID":          "ID",
+		"already_Set": "ALREADY_SET",
+	}
+
+	for in, want := range tests {
+		if got := screamingSnake(in); got != want {
+			t.Errorf("screamingSnake(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestUnmarshalAutoKeys(t *testing.T) {
+	type Database struct {
+		Host     string
+		Port     int `env:"PORT,default=5432"`
+		MaxConns int
+	}
+
+	type Config struct {
+		APIKey   string
+		Timeout  time.Duration
+		Started  time.Time
+		Database Database
+		Cache    Database `envPrefix:"REDIS_"`
+		Ignored  string   `env:"-"`
+		internal string
+	}
+
+	AutoKeys = true
+	defer func() { AutoKeys = false }()
+
+	envs := map[string]string{
+		"API_KEY":            "secret",
+		"TIMEOUT":            "5s",
+		"STARTED":            "2024-01-02T03:04:05Z",
+		"DATABASE_HOST":      "db",
+		"DATABASE_MAX_CONNS": "10",
+		"REDIS_HOST":         "cache",
+		"REDIS_PORT":         "6379",
+		"IGNORED":            "x",
+		"INTERNAL":           "x",
+	}
+	var cfg Config
+	if err := Unmarshal(envs, &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	want := Config{
+		APIKey:   "secret",
+		Timeout:  5 * time.Second,
+		Started:  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Database: Database{Host: "db", Port: 5432, MaxConns: 10},
+		Cache:    Database{Host: "cache", Port: 6379},
+	}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("Unmarshal() = %+v, want %+v", cfg, want)
+	}
+
+	out, err := Marshal(want)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if out["DATABASE_MAX_CONNS"] != "10" || out["REDIS_PORT"] != "6379" || out["API_KEY"] != "secret" {
+		t.Errorf("Marshal() = %v", out)
+	}
+}
+
+func TestUnmarshalConsumeKeys(t *testing.T) {
+	type Config struct {
+		Host string `env:"HOST"`
+	}
+
+	envs := map[string]string{"HOST": "localhost", "EXTRA": "x"}
+
+	var a, b Config
+	if err := Unmarshal(envs, &a); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if err := Unmarshal(envs, &b); err != nil || b.Host != "localhost" {
+		t.Errorf("second Unmarshal() = %+v, %v", b, err)
+	}
+	if len(envs) != 2 {
+		t.Errorf("envs was modified: %v", envs)
+	}
+
+	ConsumeKeys = true
+	defer func() { ConsumeKeys = false }()
+
+	if err := Unmarshal(envs, &a); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if want := map[string]string{"EXTRA": "x"}; !reflect.DeepEqual(envs, want) {
+		t.Errorf("envs = %v, want %v", envs, want)
+	}
+}
+
+func TestUnmarshalFieldErrors(t *testing.T) {
+	type Database struct {
+		Port int `env:"PORT"`
+	}
+
+	type Config struct {
+		Host string   `env:"HOST,required"`
+		Port int      `env:"PORT,max=100"`
+		DB   Database `envPrefix:"DB_"`
+	}
+
+	envs := map[string]string{"PORT": "8080", "DB_PORT": "abc"}
+	var cfg Config
+	err := Unmarshal(envs, &cfg)
+
+	var agg Errors
+	if !errors.As(err, &agg) || len(agg) != 3 {
+		t.Fatalf("Unmarshal() error = %#v, want Errors of 3", err)
+	}
+	if !errors.Is(err, ErrRequired) || !errors.Is(err, strconv.ErrSyntax) {
+		t.Errorf("Unmarshal() error = %v, want ErrRequired and strconv.ErrSyntax", err)
+	}
+
+	got := FieldErrors(err)
+	want := []FieldError{
+		{Struct: "Config", Field: "Host", Key: "HOST"},
+		{Struct: "Config", Field: "Port", Key: "PORT", Value: "8080"},
+		{Struct: "Database", Field: "DB.Port", Key: "DB_PORT", Value: "abc"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("FieldErrors() = %v", got)
+	}
+	for i, fe := range got {
+		fe := *fe
+		fe.Err = nil
+		if fe != want[i] {
+			t.Errorf("FieldErrors()[%d] = %+v, want %+v", i, fe, want[i])
+		}
+	}
+
+	if msg := got[1].Error(); msg != "PORT: must be at most 100, got 8080" {
+		t.Errorf("Error() = %q", msg)
+	}
+}
@@ -0,0 +1,130 @@
+package envParser
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"sync"
+)
+
+var (
+	implementations   = map[reflect.Type]map[string]func() interface{}{}
+	implementationsMu sync.RWMutex
+
+	parsers   = map[reflect.Type]func(string) (interface{}, error){}
+	parsersMu sync.RWMutex
+)
+
+// RegisterParser registers a function that decodes values of type t.
+// Registered parsers take precedence over built-in decoding, including
+// encoding.TextUnmarshaler, and apply to slice elements and map values too.
+// The parser must return a value assignable to t. Passing a nil parser removes
+// the registration.
+// This function is thread-safe.
+func RegisterParser(t reflect.Type, parser func(string) (interface{}, error)) {
+	parsersMu.Lock()
+	defer parsersMu.Unlock()
+
+	if parser == nil {
+		delete(parsers, t)
+		return
+	}
+	parsers[t] = parser
+}
+
+func getParser(t reflect.Type) (func(string) (interface{}, error), bool) {
+	parsersMu.RLock()
+	defer parsersMu.RUnlock()
+	parser, ok := parsers[t]
+	return parser, ok
+}
+
+// setParsed decodes value with parser and assigns the result to f.
+func setParsed(t reflect.Type, f reflect.Value, value string, parser func(string) (interface{}, error)) error {
+	result, err := parser(value)
+	if err != nil {
+		return err
+	}
+
+	rv := reflect.ValueOf(result)
+	if !rv.IsValid() || !rv.Type().AssignableTo(t) {
+		return fmt.Errorf("%w: parser for %s returned %T", ErrUnsupportedType, t, result)
+	}
+
+	f.Set(rv)
+	return nil
+}
+
+// RegisterImplementation registers a factory for a concrete implementation of
+// the interface type iface under the given name. A field of type iface tagged
+// `env:"STORE_TYPE"` is populated by calling the factory registered under the
+// value of STORE_TYPE. If the factory returns a pointer to a struct, its own
+// tagged fields are unmarshaled from the same environment.
+// It panics if iface is not an interface type or factory is nil.
+// This function is thread-safe.
+func RegisterImplementation(iface reflect.Type, name string, factory func() interface{}) {
+	if iface == nil || iface.Kind() != reflect.Interface {
+		panic(fmt.Sprintf("envParser: RegisterImplementation of non-interface type %v", iface))
+	}
+	if factory == nil {
+		panic("envParser: RegisterImplementation factory is nil")
+	}
+
+	implementationsMu.Lock()
+	defer implementationsMu.Unlock()
+
+	if implementations[iface] == nil {
+		implementations[iface] = map[string]func() interface{}{}
+	}
+	implementations[iface][name] = factory
+}
+
+func getImplementation(iface reflect.Type, name string) (func() interface{}, bool) {
+	implementationsMu.RLock()
+	defer implementationsMu.RUnlock()
+	factory, ok := implementations[iface][name]
+	return factory, ok
+}
+
+// setImplementation allocates the implementation of f's interface type
+// registered under name, unmarshals into it and assigns it to f.
+func (d *decoder) setImplementation(envs map[string]string, f reflect.Value, name string, sc scope) error {
+	iface := f.Type()
+
+	factory, ok := getImplementation(iface, name)
+	if !ok {
+		return fmt.Errorf("%w: no implementation %q registered for %s", ErrUnsupportedType, name, iface)
+	}
+
+	impl := factory()
+	rv := reflect.ValueOf(impl)
+	if !rv.IsValid() || !rv.Type().Implements(iface) {
+		return fmt.Errorf("%w: implementation %q does not implement %s", ErrUnsupportedType, name, iface)
+	}
+
+	if rv.Kind() == reflect.Ptr && rv.Elem().Kind() == reflect.Struct {
+		if err := d.unmarshal(envs, impl, sc); err != nil {
+			return err
+		}
+	}
+
+	f.Set(rv)
+	return nil
+}
+
+// implementationName returns the name under which a factory producing values
+// of type concrete is registered for iface. Factories are called to find it.
+func implementationName(iface, concrete reflect.Type) (string, bool) {
+	implementationsMu.RLock()
+	factories := maps.Clone(implementations[iface])
+	implementationsMu.RUnlock()
+
+	for _, name := range slices.Sorted(maps.Keys(factories)) {
+		if reflect.TypeOf(factories[name]()) == concrete {
+			return name, true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,172 @@
+package envParser
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type testStorage interface {
+	Name() string
+}
+
+type testMemoryStorage struct {
+	Size int `env:"MEMORY_SIZE,default=16"`
+}
+
+func (s *testMemoryStorage) Name() string { return "memory" }
+
+type testDiskStorage struct {
+	Path string `env:"DISK_PATH,required"`
+}
+
+func (s *testDiskStorage) Name() string { return "disk" }
+
+func TestRegisterImplementation(t *testing.T) {
+	iface := reflect.TypeFor[testStorage]()
+	RegisterImplementation(iface, "memory", func() interface{} { return &testMemoryStorage{} })
+	RegisterImplementation(iface, "disk", func() interface{} { return &testDiskStorage{} })
+
+	type Config struct {
+		Store testStorage `env:"STORE_TYPE,default=memory"`
+	}
+
+	t.Run("default implementation", func(t *testing.T) {
+		var cfg Config
+		if err := Unmarshal(map[string]string{}, &cfg); err != nil {
+			t.Fatalf("Unmarshal() error = %v", err)
+		}
+
+		mem, ok := cfg.Store.(*testMemoryStorage)
+		if !ok || mem.Size != 16 {
+			t.Errorf("Store = %#v, want memory storage with size 16", cfg.Store)
+		}
+	})
+
+	t.Run("selected implementation", func(t *testing.T) {
+		var cfg Config
+		envs := map[string]string{"STORE_TYPE": "disk", "DISK_PATH": "/data"}
+		if err := Unmarshal(envs, &cfg); err != nil {
+			t.Fatalf("Unmarshal() error = %v", err)
+		}
+
+		disk, ok := cfg.Store.(*testDiskStorage)
+		if !ok || disk.Path != "/data" {
+			t.Errorf("Store = %#v, want disk storage at /data", cfg.Store)
+		}
+	})
+
+	t.Run("implementation fields are validated", func(t *testing.T) {
+		var cfg Config
+		if err := Unmarshal(map[string]string{"STORE_TYPE": "disk"}, &cfg); err == nil {
+			t.Error("expected error for missing DISK_PATH")
+		}
+	})
+
+	t.Run("unknown implementation", func(t *testing.T) {
+		var cfg Config
+		err := Unmarshal(map[string]string{"STORE_TYPE": "s3"}, &cfg)
+		if !errors.Is(err, ErrUnsupportedType) {
+			t.Errorf("expected ErrUnsupportedType, got: %v", err)
+		}
+	})
+}
+
+func TestRegisterImplementationPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for non-interface type")
+		}
+	}()
+
+	RegisterImplementation(reflect.TypeFor[string](), "x", func() interface{} { return "" })
+}
+
+type testDecimal struct {
+	units int64
+	scale int
+}
+
+func TestRegisterParser(t *testing.T) {
+	decimalType := reflect.TypeFor[testDecimal]()
+	RegisterParser(decimalType, func(s string) (interface{}, error) {
+		whole, frac, _ := strings.Cut(s, ".")
+		n, err := strconv.ParseInt(whole+frac, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return testDecimal{units: n, scale: len(frac)}, nil
+	})
+	defer RegisterParser(decimalType, nil)
+
+	type Config struct {
+		Price  testDecimal            `env:"PRICE"`
+		Ptr    *testDecimal           `env:"PTR_PRICE"`
+		Prices []testDecimal          `env:"PRICES"`
+		ByName map[string]testDecimal `env:"BY_NAME"`
+	}
+
+	envs := map[string]string{
+		"PRICE":     "12.50",
+		"PTR_PRICE": "1.5",
+		"PRICES":    "1.1;2.25",
+		"BY_NAME":   "tea:3.0",
+	}
+	var cfg Config
+	if err := Unmarshal(envs, &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if cfg.Price != (testDecimal{1250, 2}) || cfg.Ptr == nil || *cfg.Ptr != (testDecimal{15, 1}) {
+		t.Errorf("Price = %+v, Ptr = %+v", cfg.Price, cfg.Ptr)
+	}
+	if len(cfg.Prices) != 2 || cfg.Prices[1] != (testDecimal{225, 2}) || cfg.ByName["tea"] != (testDecimal{30, 1}) {
+		t.Errorf("Prices = %+v, ByName = %+v", cfg.Prices, cfg.ByName)
+	}
+
+	if err := Unmarshal(map[string]string{"PRICE": "abc"}, &cfg); err == nil {
+		t.Error("expected parser error")
+	}
+}
+
+func TestRegisterParserOverridesKind(t *testing.T) {
+	type percent int
+	percentType := reflect.TypeFor[percent]()
+	RegisterParser(percentType, func(s string) (interface{}, error) {
+		n, err := strconv.Atoi(strings.TrimSuffix(s, "%"))
+		return percent(n), err
+	})
+	defer RegisterParser(percentType, nil)
+
+	type Config struct {
+		Ratio percent `env:"RATIO"`
+		Plain int     `env:"PLAIN"`
+	}
+
+	var cfg Config
+	if err := Unmarshal(map[string]string{"RATIO": "75%", "PLAIN": "5"}, &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if cfg.Ratio != 75 || cfg.Plain != 5 {
+		t.Errorf("Unmarshal() = %+v", cfg)
+	}
+}
+
+func TestRegisterParserWrongType(t *testing.T) {
+	type token string
+	tokenType := reflect.TypeFor[token]()
+	RegisterParser(tokenType, func(s string) (interface{}, error) { return 42, nil })
+	defer RegisterParser(tokenType, nil)
+
+	type Config struct {
+		Token token `env:"TOKEN"`
+	}
+
+	var cfg Config
+	err := Unmarshal(map[string]string{"TOKEN": "x"}, &cfg)
+	if !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("expected ErrUnsupportedType, got: %v", err)
+	}
+}
@@ -0,0 +1,162 @@
+package envParser
+
+import (
+	"maps"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Report lists which input keys populated a field during an unmarshal call.
+type Report struct {
+	// Consumed holds the keys that populated a field, sorted.
+	Consumed []string
+	// Remaining holds the keys no field consumed, sorted.
+	Remaining []string
+}
+
+// UnmarshalWithReport behaves like Unmarshal and additionally reports which
+// keys of envs were consumed and which remain, e.g. to audit unexpected
+// configuration. envs is not modified. The report is returned even when an
+// error occurs.
+func UnmarshalWithReport(envs map[string]string, v interface{}) (Report, error) {
+	opts := DefaultOptions()
+	opts.ConsumeKeys = true
+
+	remaining := maps.Clone(envs)
+	err := newDecoder(opts).decode(remaining, v)
+
+	var r Report
+	for _, key := range slices.Sorted(maps.Keys(envs)) {
+		if _, ok := remaining[key]; ok {
+			r.Remaining = append(r.Remaining, key)
+		} else {
+			r.Consumed = append(r.Consumed, key)
+		}
+	}
+
+	return r, err
+}
+
+// DiffResult compares the variables a config needs with an environment.
+type DiffResult struct {
+	// Missing holds the required keys that are not set, in field order.
+	Missing []string
+	// Defaulted holds the keys that are unset or empty and fall back to
+	// their default, in field order.
+	Defaulted []string
+	// Extraneous holds the keys of the environment no field reads, sorted.
+	Extraneous []string
+}
+
+// Diff compares the tagged fields of v with envs without populating v, e.g.
+// for pre-flight checks and dry runs. Values are not parsed, so Diff does
+// not report invalid values; use Unmarshal on a scratch value for that.
+// Elements of struct slices and maps are not matched and end up in
+// Extraneous. v must be a struct or a pointer to a struct.
+func Diff(v interface{}, envs map[string]string) (DiffResult, error) {
+	t, err := structType(v)
+	if err != nil {
+		return DiffResult{}, err
+	}
+
+	d := newDecoder(DefaultOptions())
+	d.prepare(envs)
+
+	var r DiffResult
+	used := make(map[string]bool)
+	sc := scope{tag: d.opts.Tag, prefix: d.opts.Prefix, auto: d.opts.AutoKeys}
+	walkFields(t, sc, func(_ string, f reflect.StructField, tf tagField) {
+		key, value, ok := d.lookupField(envs, tf)
+		if !ok && tf.File {
+			key, value, ok = d.lookup(envs, tf.Key+"_FILE")
+		}
+		if ok {
+			used[key] = true
+		}
+
+		switch {
+		case ok && value != "":
+		case ok && !emptyUsesDefault(f.Type, tf) && !tf.NotEmpty:
+		case tf.Default != "":
+			r.Defaulted = append(r.Defaulted, tf.Key)
+		case (!ok && d.required(tf)) || (ok && tf.NotEmpty):
+			r.Missing = append(r.Missing, tf.Key)
+		}
+	})
+
+	for _, key := range slices.Sorted(maps.Keys(envs)) {
+		if !used[key] {
+			r.Extraneous = append(r.Extraneous, key)
+		}
+	}
+
+	return r, nil
+}
+
+// Sources reported in an Origin.
+const (
+	// SourceEnv marks values read from the environment map or the process
+	// environment.
+	SourceEnv = "env"
+	// SourceDefault marks values taken from a field's default.
+	SourceDefault = "default"
+)
+
+// Origin describes where the value of a field came from.
+type Origin struct {
+	// Key is the variable that was read, e.g. an alias or a KEY_FILE
+	// variable. It is empty for defaults.
+	Key string
+	// Source is SourceEnv, SourceDefault or the path of the .env file that
+	// set Key.
+	Source string
+}
+
+// Provenance maps the Go path of each populated field, e.g. "DB.Port", to
+// the origin of its value. Fields left unset are not listed.
+type Provenance map[string]Origin
+
+// UnmarshalWithProvenance behaves like Unmarshal and additionally reports
+// where each field's value came from. Every key of envs is reported as
+// SourceEnv. The provenance is returned even when an error occurs and then
+// covers the fields that were set.
+func UnmarshalWithProvenance(envs map[string]string, v interface{}) (Provenance, error) {
+	return unmarshalWithProvenance(envs, nil, v)
+}
+
+// UnmarshalFromFilesWithProvenance behaves like UnmarshalFromFiles and
+// additionally reports, for each field, whether its value came from the
+// system environment, one of the .env files in paths or a default, e.g. to
+// debug which layer overrides a setting.
+func UnmarshalFromFilesWithProvenance(paths []string, v interface{}) (Provenance, error) {
+	environ := os.Environ()
+	origins := make(map[string]string, len(environ))
+	for _, entry := range environ {
+		key, _, _ := strings.Cut(entry, "=")
+		origins[key] = SourceEnv
+	}
+
+	entries, err := readEnvFiles(paths, environ, origins)
+	if err != nil {
+		return nil, err
+	}
+
+	envs, err := EnvironToMap(entries)
+	if err != nil {
+		return nil, err
+	}
+
+	return unmarshalWithProvenance(envs, origins, v)
+}
+
+func unmarshalWithProvenance(envs, origins map[string]string, v interface{}) (Provenance, error) {
+	d := newDecoder(DefaultOptions())
+	d.origins = origins
+	d.provenance = make(Provenance)
+
+	err := d.decode(envs, v)
+
+	return d.provenance, err
+}
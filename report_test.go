@@ -0,0 +1,167 @@
+package envParser
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestUnmarshalWithReport(t *testing.T) {
+	type Config struct {
+		Host  string `env:"HOST"`
+		Port  int    `env:"PORT,default=8080"`
+		Count int    `env:"COUNT"`
+	}
+
+	envs := map[string]string{
+		"HOST":   "localhost",
+		"COUNT":  "not-a-number",
+		"EXTRA":  "x",
+		"DB_URL": "postgres://db",
+	}
+
+	var cfg Config
+	r, err := UnmarshalWithReport(envs, &cfg)
+	if err == nil {
+		t.Error("expected error for COUNT")
+	}
+
+	want := Report{Consumed: []string{"HOST"}, Remaining: []string{"COUNT", "DB_URL", "EXTRA"}}
+	if !reflect.DeepEqual(r, want) {
+		t.Errorf("UnmarshalWithReport() = %+v, want %+v", r, want)
+	}
+	if len(envs) != 4 {
+		t.Errorf("envs was modified: %v", envs)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	type DB struct {
+		Host string `env:"HOST,required"`
+		Port int    `env:"PORT,default=5432"`
+	}
+
+	type Config struct {
+		Name  string `env:"NAME|APP_NAME,required"`
+		Token string `env:"TOKEN,notEmpty"`
+		Debug bool   `env:"DEBUG"`
+		Level string `env:"LEVEL,default=info"`
+		Tries int    `env:"TRIES,default=3"`
+		Key   string `env:"KEY,file,required"`
+		DB    DB     `envPrefix:"DB_"`
+	}
+
+	envs := map[string]string{
+		"APP_NAME": "svc",
+		"TOKEN":    "",
+		"TRIES":    "",
+		"KEY_FILE": "/run/secrets/key",
+		"DB_PORT":  "5433",
+		"DB_PASS":  "x",
+		"PATH":     "/bin",
+	}
+
+	var cfg Config
+	got, err := Diff(&cfg, envs)
+	if err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+
+	want := DiffResult{
+		Missing:    []string{"TOKEN", "DB_HOST"},
+		Defaulted:  []string{"LEVEL", "TRIES"},
+		Extraneous: []string{"DB_PASS", "PATH"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() = %+v, want %+v", got, want)
+	}
+	if cfg != (Config{}) {
+		t.Errorf("Diff() populated v: %+v", cfg)
+	}
+
+	if _, err := Diff(1, envs); err != ErrInvalidValue {
+		t.Errorf("Diff(1) error = %v, want ErrInvalidValue", err)
+	}
+}
+
+func TestUnmarshalWithProvenance(t *testing.T) {
+	type DB struct {
+		Port int `env:"PORT,default=5432"`
+	}
+
+	type Config struct {
+		Name  string `env:"NAME|APP_NAME"`
+		Level string `env:"LEVEL,default=info"`
+		Debug bool   `env:"DEBUG"`
+		DB    DB     `envPrefix:"DB_"`
+	}
+
+	var cfg Config
+	p, err := UnmarshalWithProvenance(map[string]string{"APP_NAME": "svc", "LEVEL": ""}, &cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Provenance{
+		"Name":    {Key: "APP_NAME", Source: SourceEnv},
+		"Level":   {Key: "LEVEL", Source: SourceEnv},
+		"DB.Port": {Source: SourceDefault},
+	}
+	if !reflect.DeepEqual(p, want) {
+		t.Errorf("UnmarshalWithProvenance() = %+v, want %+v", p, want)
+	}
+}
+
+func TestUnmarshalFromFilesWithProvenance(t *testing.T) {
+	defer func() { Duplicates = LastWins }()
+
+	dir := t.TempDir()
+	base := filepath.Join(dir, ".env")
+	local := filepath.Join(dir, ".env.local")
+	if err := os.WriteFile(base, []byte("HOST=db\nPORT=5432\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(local, []byte("PORT=5433\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PROVENANCE_USER", "admin")
+
+	type Config struct {
+		Host string `env:"HOST"`
+		Port int    `env:"PORT"`
+		User string `env:"PROVENANCE_USER"`
+	}
+
+	tests := []struct {
+		name string
+		mode DuplicateMode
+		want Provenance
+	}{
+		{"last wins", LastWins, Provenance{
+			"Host": {Key: "HOST", Source: base},
+			"Port": {Key: "PORT", Source: local},
+			"User": {Key: "PROVENANCE_USER", Source: SourceEnv},
+		}},
+		{"first wins", FirstWins, Provenance{
+			"Host": {Key: "HOST", Source: base},
+			"Port": {Key: "PORT", Source: base},
+			"User": {Key: "PROVENANCE_USER", Source: SourceEnv},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Duplicates = tt.mode
+
+			var cfg Config
+			p, err := UnmarshalFromFilesWithProvenance([]string{base, local}, &cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(p, tt.want) {
+				t.Errorf("UnmarshalFromFilesWithProvenance() = %+v, want %+v", p, tt.want)
+			}
+		})
+	}
+}
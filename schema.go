@@ -0,0 +1,140 @@
+package envParser
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// jsonSchemaDraft is the JSON Schema dialect emitted by JSONSchema.
+const jsonSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// schemaProperty is the JSON Schema of a single environment variable.
+type schemaProperty struct {
+	Type        string   `json:"type"`
+	Description string   `json:"description,omitempty"`
+	Default     string   `json:"default,omitempty"`
+	Examples    []string `json:"examples,omitempty"`
+	Enum        []string `json:"enum,omitempty"`
+	Pattern     string   `json:"pattern,omitempty"`
+	MinLength   int      `json:"minLength,omitempty"`
+	Deprecated  bool     `json:"deprecated,omitempty"`
+	GoType      string   `json:"x-go-type"`
+}
+
+// schema is a JSON Schema document describing an environment.
+type schema struct {
+	Schema               string                    `json:"$schema"`
+	Type                 string                    `json:"type"`
+	Properties           map[string]schemaProperty `json:"properties"`
+	Required             []string                  `json:"required,omitempty"`
+	AdditionalProperties bool                      `json:"additionalProperties"`
+}
+
+// JSONSchema returns a JSON Schema for the environment read into v: an
+// object with one string property per variable, carrying its description,
+// default, allowed values and a pattern for numeric and bool fields, and
+// the required variables. The allowed values of slice and array fields
+// become a pattern checking each element. Other variables are allowed, since environments
+// hold more than the config. v must be a struct or a pointer to a struct.
+func JSONSchema(v interface{}) ([]byte, error) {
+	t, err := structType(v)
+	if err != nil {
+		return nil, err
+	}
+
+	s := schema{
+		Schema:               jsonSchemaDraft,
+		Type:                 "object",
+		Properties:           make(map[string]schemaProperty),
+		AdditionalProperties: true,
+	}
+	walkFields(t, scope{tag: Tag, auto: AutoKeys}, func(_ string, sf reflect.StructField, tf tagField) {
+		p := schemaProperty{
+			Type:        "string",
+			Description: tf.Doc,
+			Default:     tf.Default,
+			Enum:        tf.OneOf,
+			Pattern:     tf.Pattern,
+			Deprecated:  tf.Deprecated != "",
+			GoType:      sf.Type.String(),
+		}
+		if tf.Example != "" {
+			p.Examples = []string{tf.Example}
+		}
+		if k := derefType(sf.Type).Kind(); k == reflect.Slice || k == reflect.Array {
+			// Allowed values and patterns apply to each element, which a
+			// pattern can only express for the allowed values.
+			p.Enum, p.Pattern = nil, elementPattern(tf)
+		} else if p.Pattern == "" && len(p.Enum) == 0 {
+			p.Pattern = typePattern(sf.Type, tf)
+		}
+		if tf.NotEmpty {
+			p.MinLength = 1
+		}
+
+		s.Properties[tf.Key] = p
+		// A default satisfies required, as it does for Unmarshal.
+		if (tf.Required || tf.NotEmpty) && tf.Default == "" {
+			s.Required = append(s.Required, tf.Key)
+		}
+	})
+
+	return json.MarshalIndent(s, "", "  ")
+}
+
+// integerDigits matches the decimal, hex, octal and binary integer literals
+// accepted by Unmarshal, without a sign.
+const integerDigits = "([0-9]+|0[xX][0-9a-fA-F_]+|0[oO][0-7_]+|0[bB][01_]+)"
+
+// elementPattern returns a regular expression matching a collection value
+// whose elements, split as tf.split does, are all allowed by the oneof
+// option, or "" if the option is not set.
+func elementPattern(tf tagField) string {
+	if len(tf.OneOf) == 0 {
+		return ""
+	}
+
+	quoted := make([]string, len(tf.OneOf))
+	for i, v := range tf.OneOf {
+		quoted[i] = regexp.QuoteMeta(v)
+	}
+
+	elem := "(" + strings.Join(quoted, "|") + ")"
+	if tf.Trim || TrimElements {
+		elem = `\s*` + elem + `\s*`
+	}
+	if tf.OmitEmpty {
+		elem += "?"
+	}
+
+	return "^" + elem + "(" + regexp.QuoteMeta(tf.separator()) + elem + ")*$"
+}
+
+// typePattern returns a regular expression matching the values accepted for
+// plain numeric and bool fields of type t, or "" for other types.
+func typePattern(t reflect.Type, tf tagField) string {
+	t = derefType(t)
+	if _, ok := getParser(t); ok || reflect.PointerTo(t).Implements(textUnmarshalerType) || tf.Unit != "" {
+		return ""
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		if LenientBools {
+			return ""
+		}
+		return "^(1|t|T|TRUE|true|True|0|f|F|FALSE|false|False)$"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if t == reflect.TypeFor[time.Duration]() {
+			return ""
+		}
+		return "^[+-]?" + integerDigits + "$"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "^" + integerDigits + "$"
+	default:
+		return ""
+	}
+}
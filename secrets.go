@@ -0,0 +1,61 @@
+package envParser
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultSecretsDir is where Docker Swarm and Compose mount secrets.
+const DefaultSecretsDir = "/run/secrets"
+
+// ReadSecretsDir reads every file in dir as a variable named after the file,
+// upper-cased with "-" and "." replaced by "_", so a db-password secret
+// becomes DB_PASSWORD. Surrounding whitespace, such as a trailing newline,
+// is trimmed from the contents. Hidden files and subdirectories are
+// skipped, and a missing dir yields an empty map, so the same code runs
+// where no secrets are mounted.
+func ReadSecretsDir(dir string) (map[string]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	envs := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		// Stat follows symlinks, which Kubernetes uses for mounted files.
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if info.IsDir() {
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		envs[EnvKey(entry.Name())] = strings.TrimSpace(string(data))
+	}
+
+	return envs, nil
+}
+
+// SecretsDirSource returns a Source reading dir like ReadSecretsDir.
+func SecretsDirSource(dir string) Source {
+	return SourceFunc(func(context.Context) (map[string]string, error) {
+		return ReadSecretsDir(dir)
+	})
+}
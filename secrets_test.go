@@ -0,0 +1,99 @@
+package envParser
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeSecrets(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestReadSecretsDir(t *testing.T) {
+	dir := writeSecrets(t, map[string]string{
+		"db_password": "s3cret\n",
+		"api-key":     "  abc  ",
+		".hidden":     "x",
+	})
+	if err := os.Mkdir(filepath.Join(dir, "nested"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(dir, "db_password"), filepath.Join(dir, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ReadSecretsDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"DB_PASSWORD": "s3cret", "API_KEY": "abc", "LINK": "s3cret"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadSecretsDir() = %v, want %v", got, want)
+	}
+
+	got, err = ReadSecretsDir(filepath.Join(dir, "missing"))
+	if err != nil || len(got) != 0 {
+		t.Errorf("ReadSecretsDir(missing) = %v, %v, want empty map", got, err)
+	}
+}
+
+func TestUnmarshalSecretsDir(t *testing.T) {
+	defer func() { SecretsDir = "" }()
+
+	type Config struct {
+		Password string `env:"DB_PASSWORD,required"`
+		APIKey   string `env:"API_KEY"`
+	}
+
+	SecretsDir = writeSecrets(t, map[string]string{"db_password": "s3cret\n", "api_key": "file"})
+	envs := map[string]string{"API_KEY": "env"}
+
+	var cfg Config
+	if err := Unmarshal(envs, &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if want := (Config{Password: "s3cret", APIKey: "env"}); cfg != want {
+		t.Errorf("Unmarshal() = %+v, want %+v", cfg, want)
+	}
+	if len(envs) != 1 {
+		t.Errorf("envs was modified: %v", envs)
+	}
+
+	SecretsDir = ""
+	if err := Unmarshal(envs, &Config{}); err == nil {
+		t.Error("expected required error without SecretsDir")
+	}
+}
+
+func TestUnmarshalSecretsDirConsumeKeys(t *testing.T) {
+	type Config struct {
+		Password string `env:"DB_PASSWORD"`
+		APIKey   string `env:"API_KEY"`
+	}
+
+	opts := DefaultOptions()
+	opts.ConsumeKeys = true
+	opts.SecretsDir = writeSecrets(t, map[string]string{"db_password": "s3cret", "unused": "x"})
+	envs := map[string]string{"API_KEY": "env", "OTHER": "y"}
+
+	var cfg Config
+	if err := UnmarshalWithOptions(envs, &cfg, opts); err != nil {
+		t.Fatal(err)
+	}
+	if want := (Config{Password: "s3cret", APIKey: "env"}); cfg != want {
+		t.Errorf("Unmarshal() = %+v, want %+v", cfg, want)
+	}
+	if want := map[string]string{"OTHER": "y"}; !reflect.DeepEqual(envs, want) {
+		t.Errorf("envs = %v, want %v", envs, want)
+	}
+}
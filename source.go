@@ -0,0 +1,108 @@
+package envParser
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"strings"
+)
+
+// Source provides environment variables from a single origin, such as the
+// process environment, .env files or a remote config service.
+type Source interface {
+	// Load returns the variables of the source as a KEY to value map.
+	Load(ctx context.Context) (map[string]string, error)
+}
+
+// SourceFunc adapts a function to the Source interface.
+type SourceFunc func(ctx context.Context) (map[string]string, error)
+
+// Load calls f(ctx).
+func (f SourceFunc) Load(ctx context.Context) (map[string]string, error) {
+	return f(ctx)
+}
+
+// MapSource returns a Source serving a copy of envs.
+func MapSource(envs map[string]string) Source {
+	return SourceFunc(func(context.Context) (map[string]string, error) {
+		return maps.Clone(envs), nil
+	})
+}
+
+// EnvSource returns a Source reading the process environment at load time.
+func EnvSource() Source {
+	return SourceFunc(func(context.Context) (map[string]string, error) {
+		return EnvironToMap(os.Environ())
+	})
+}
+
+// FileSource returns a Source reading the .env files in paths, layered in
+// order like ReadEnvFiles.
+func FileSource(paths ...string) Source {
+	return SourceFunc(func(context.Context) (map[string]string, error) {
+		return ReadEnvFiles(paths...)
+	})
+}
+
+// Resolver merges an ordered chain of sources, e.g. .env files, then the
+// process environment, then a remote service. Later sources override
+// earlier ones; set Duplicates to FirstWins to reverse this. Field defaults
+// apply below every source. A Resolver is itself a Source, so chains can be
+// nested.
+type Resolver struct {
+	sources []Source
+}
+
+// NewResolver returns a Resolver over sources, from lowest to highest
+// precedence.
+func NewResolver(sources ...Source) *Resolver {
+	return &Resolver{sources: sources}
+}
+
+// Load loads every source in order and merges the results. It stops at the
+// first source that fails.
+func (r *Resolver) Load(ctx context.Context) (map[string]string, error) {
+	envs := make(map[string]string)
+	for i, s := range r.sources {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		m, err := s.Load(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("source %d: %w", i, err)
+		}
+
+		for k, v := range m {
+			if _, exists := envs[k]; exists && Duplicates == FirstWins {
+				continue
+			}
+			envs[k] = v
+		}
+	}
+
+	return envs, nil
+}
+
+// Unmarshal loads the merged variables and unmarshals them into v.
+// v must be a non-nil pointer to a struct.
+func (r *Resolver) Unmarshal(ctx context.Context, v interface{}) error {
+	envs, err := r.Load(ctx)
+	if err != nil {
+		return err
+	}
+
+	return Unmarshal(envs, v)
+}
+
+// envKeyReplacer maps the separators of config keys to underscores.
+var envKeyReplacer = strings.NewReplacer("-", "_", ".", "_", "/", "_")
+
+// EnvKey returns the environment key for a config key or secret name, such
+// as log-level, db.host or db/host: it is upper-cased with "-", "." and "/"
+// replaced by "_", so db-password becomes DB_PASSWORD. Sources reading other
+// formats use it to name their variables.
+func EnvKey(name string) string {
+	return strings.ToUpper(envKeyReplacer.Replace(name))
+}
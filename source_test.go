@@ -0,0 +1,83 @@
+package envParser
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolver(t *testing.T) {
+	defer func() { Duplicates = LastWins }()
+
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("HOST=file\nPORT=5432\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	type Config struct {
+		Host  string `env:"HOST"`
+		Port  int    `env:"PORT"`
+		Level string `env:"LEVEL,default=info"`
+	}
+
+	tests := []struct {
+		name string
+		mode DuplicateMode
+		want Config
+	}{
+		{"last wins", LastWins, Config{Host: "remote", Port: 5432, Level: "info"}},
+		{"first wins", FirstWins, Config{Host: "file", Port: 5432, Level: "info"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Duplicates = tt.mode
+
+			r := NewResolver(FileSource(path), MapSource(map[string]string{"HOST": "remote"}))
+
+			var cfg Config
+			if err := r.Unmarshal(context.Background(), &cfg); err != nil {
+				t.Fatal(err)
+			}
+			if cfg != tt.want {
+				t.Errorf("Unmarshal() = %+v, want %+v", cfg, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolverErrors(t *testing.T) {
+	errBoom := errors.New("boom")
+	failing := SourceFunc(func(context.Context) (map[string]string, error) {
+		return nil, errBoom
+	})
+
+	r := NewResolver(MapSource(nil), failing)
+	if _, err := r.Load(context.Background()); !errors.Is(err, errBoom) || err.Error() != "source 1: boom" {
+		t.Errorf("Load() error = %v, want source 1: boom", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := NewResolver(MapSource(nil)).Load(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Load() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestEnvKey(t *testing.T) {
+	tests := map[string]string{
+		"db-password": "DB_PASSWORD",
+		"db.host":     "DB_HOST",
+		"db/host":     "DB_HOST",
+		"log_level":   "LOG_LEVEL",
+		"PORT":        "PORT",
+	}
+
+	for name, want := range tests {
+		if got := EnvKey(name); got != want {
+			t.Errorf("EnvKey(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,315 @@
+package envParser
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// FieldSpec describes a single environment-backed field.
+type FieldSpec struct {
+	Key        string   `json:"key"`
+	Aliases    []string `json:"aliases,omitempty"`
+	Type       string   `json:"type"`
+	Required   bool     `json:"required"`
+	Default    string   `json:"default,omitempty"`
+	Example    string   `json:"example,omitempty"`
+	Doc        string   `json:"doc,omitempty"`
+	OneOf      []string `json:"oneof,omitempty"`
+	Deprecated string   `json:"deprecated,omitempty"`
+	Secret     bool     `json:"secret,omitempty"`
+	Pattern    string   `json:"pattern,omitempty"`
+	NotEmpty   bool     `json:"notempty,omitempty"`
+	Unit       string   `json:"unit,omitempty"`
+	Layout     string   `json:"layout,omitempty"`
+	Schemes    []string `json:"schemes,omitempty"`
+	Encoding   string   `json:"encoding,omitempty"`
+	Lenient    bool     `json:"lenient,omitempty"`
+	Presence   bool     `json:"presence,omitempty"`
+	File       bool     `json:"file,omitempty"`
+	Separator  string   `json:"separator,omitempty"`
+	Trim       bool     `json:"trim,omitempty"`
+	OmitEmpty  bool     `json:"omitempty,omitempty"`
+}
+
+// Spec returns a JSON description of every tagged field in v, including
+// nested structs. v must be a struct or a pointer to a struct.
+func Spec(v interface{}) ([]byte, error) {
+	specs, err := fieldSpecs(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.MarshalIndent(specs, "", "  ")
+}
+
+func fieldSpecs(v interface{}) ([]FieldSpec, error) {
+	t, err := structType(v)
+	if err != nil {
+		return nil, err
+	}
+
+	specs := make([]FieldSpec, 0, t.NumField())
+	walkFields(t, scope{tag: Tag, auto: AutoKeys}, func(_ string, sf reflect.StructField, tf tagField) {
+		spec := FieldSpec{
+			Key:        tf.Key,
+			Aliases:    tf.Aliases,
+			Type:       sf.Type.String(),
+			Required:   tf.Required || tf.NotEmpty,
+			Default:    tf.Default,
+			Example:    tf.Example,
+			Doc:        tf.Doc,
+			OneOf:      tf.OneOf,
+			Deprecated: tf.Deprecated,
+			Secret:     tf.Secret,
+			Pattern:    tf.Pattern,
+			NotEmpty:   tf.NotEmpty,
+			Unit:       tf.Unit,
+			Layout:     tf.Layout,
+			Schemes:    tf.Schemes,
+			Encoding:   tf.Encoding,
+			Lenient:    LenientBools && derefType(sf.Type).Kind() == reflect.Bool,
+			Presence:   tf.Presence,
+			File:       tf.File,
+		}
+		if isCollectionType(derefType(sf.Type)) && !isBytes(derefType(sf.Type)) {
+			spec.Separator = tf.separator()
+			spec.Trim = tf.Trim || TrimElements
+			spec.OmitEmpty = tf.OmitEmpty
+		}
+		specs = append(specs, spec)
+	})
+
+	return specs, nil
+}
+
+// FieldInfo describes how a single field is read from the environment.
+type FieldInfo struct {
+	// Field is the dotted path of the field, e.g. DB.Port.
+	Field string
+	// Key is the environment key, including any prefix.
+	Key string
+	// Aliases are the fallback keys, in lookup order.
+	Aliases []string
+	// Type is the Go type of the field.
+	Type reflect.Type
+	// Default is the default value, empty if there is none.
+	Default string
+	// Required is set for required and notEmpty fields.
+	Required bool
+	// Separator splits slice and map values, empty for other types.
+	Separator string
+	// Description is the doc or desc tag option.
+	Description string
+	// Example is the example tag option.
+	Example string
+	// OneOf holds the allowed values, if restricted.
+	OneOf []string
+	// Deprecated is the deprecation message, empty if not deprecated.
+	Deprecated string
+	// Secret is set for fields tagged secret.
+	Secret bool
+	// Extra holds the name=value tag options the parser does not recognize,
+	// by lower-cased name, for extensions such as envvault.
+	Extra map[string]string
+}
+
+// Describe returns the metadata of every tagged field in v, including nested
+// structs, in the order Unmarshal reads them, so tools don't have to parse
+// the tags themselves. v must be a struct or a pointer to a struct.
+func Describe(v interface{}) ([]FieldInfo, error) {
+	t, err := structType(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var infos []FieldInfo
+	walkFields(t, scope{tag: Tag, auto: AutoKeys}, func(path string, sf reflect.StructField, tf tagField) {
+		info := FieldInfo{
+			Field:       path,
+			Key:         tf.Key,
+			Aliases:     tf.Aliases,
+			Type:        sf.Type,
+			Default:     tf.Default,
+			Required:    tf.Required || tf.NotEmpty,
+			Description: tf.Doc,
+			Example:     tf.Example,
+			OneOf:       tf.OneOf,
+			Deprecated:  tf.Deprecated,
+			Secret:      tf.Secret,
+			Extra:       tf.Extra,
+		}
+		if isCollectionType(derefType(sf.Type)) && !isBytes(derefType(sf.Type)) {
+			info.Separator = tf.separator()
+		}
+		infos = append(infos, info)
+	})
+
+	return infos, nil
+}
+
+// structType returns the struct type of v, which must be a struct or a
+// non-nil pointer to a struct.
+func structType(v interface{}) (reflect.Type, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, ErrInvalidValue
+		}
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return nil, ErrInvalidValue
+	}
+
+	return rv.Type(), nil
+}
+
+// walkFields calls fn with the dotted path, struct field and parsed tag of
+// every tagged field of t, descending into nested structs in the same order
+// as Unmarshal.
+func walkFields(t reflect.Type, sc scope, fn func(string, reflect.StructField, tagField)) {
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		tag := sc.fieldTag(sf)
+		if tag == "-" {
+			continue
+		}
+
+		if sf.IsExported() && sc.recurse(sf, tag) {
+			walkFields(sf.Type, sc.nested(sf), fn)
+		}
+
+		if tag == "" || !sf.IsExported() {
+			continue
+		}
+
+		fn(sc.path+sf.Name, sf, sc.parseTag(tag))
+	}
+}
+
+// specTypes maps the type names used in specs to the types CheckSpec parses
+// values as.
+var specTypes = map[string]reflect.Type{
+	"bool":          reflect.TypeFor[bool](),
+	"int":           reflect.TypeFor[int](),
+	"int8":          reflect.TypeFor[int8](),
+	"int16":         reflect.TypeFor[int16](),
+	"int32":         reflect.TypeFor[int32](),
+	"int64":         reflect.TypeFor[int64](),
+	"uint":          reflect.TypeFor[uint](),
+	"uint8":         reflect.TypeFor[uint8](),
+	"uint16":        reflect.TypeFor[uint16](),
+	"uint32":        reflect.TypeFor[uint32](),
+	"uint64":        reflect.TypeFor[uint64](),
+	"float32":       reflect.TypeFor[float32](),
+	"float64":       reflect.TypeFor[float64](),
+	"time.Duration": reflect.TypeFor[time.Duration](),
+	"time.Time":     timeType,
+	"url.URL":       urlType,
+}
+
+// CheckSpec validates envs against spec, a document produced by Spec,
+// without needing the config struct: it reports every missing required key
+// and every value that is not one of the allowed values, does not match the
+// pattern or does not parse as a basic field type. Presence, file and
+// collection fields are read as Unmarshal reads them. This lets tools such
+// as cmd/envcheck check an environment against the config of another
+// program.
+func CheckSpec(spec []byte, envs map[string]string) error {
+	var specs []FieldSpec
+	if err := json.Unmarshal(spec, &specs); err != nil {
+		return fmt.Errorf("invalid spec: %w", err)
+	}
+
+	var err error
+	for _, fs := range specs {
+		key, value, ok := fs.Key, "", false
+		for _, name := range append([]string{fs.Key}, fs.Aliases...) {
+			if value, ok = envs[name]; ok {
+				key = name
+				break
+			}
+		}
+		if !ok && fs.File {
+			// Follow the KEY_FILE convention used by container images.
+			key = fs.Key + "_FILE"
+			value, ok = envs[key]
+		}
+		if ok && value == "" && fs.Presence {
+			value = "true"
+		}
+
+		// Like Unmarshal, an empty value of a non-string field falls back to
+		// the default and is only rejected by notempty; otherwise it is
+		// checked as given.
+		typ := strings.TrimPrefix(fs.Type, "*")
+		switch {
+		case !ok:
+			if fs.Required && fs.Default == "" {
+				err = errors.Join(err, &FieldError{Key: fs.Key, Err: ErrRequired})
+			}
+			continue
+		case value == "" && fs.Default != "" && (fs.NotEmpty || typ != "string"):
+			continue
+		case value == "" && fs.NotEmpty:
+			err = errors.Join(err, &FieldError{Key: key, Err: ErrEmpty})
+			continue
+		}
+
+		// Errors of file fields show the path, not the secret it holds.
+		raw := value
+		if fs.File {
+			data, rErr := os.ReadFile(value)
+			if rErr != nil {
+				err = errors.Join(err, &FieldError{Key: key, Value: raw, Err: fmt.Errorf("cannot read file: %w", rErr)})
+				continue
+			}
+			value = strings.TrimSpace(string(data))
+		}
+
+		tf := tagField{
+			Key:       key,
+			OneOf:     fs.OneOf,
+			Pattern:   fs.Pattern,
+			Unit:      fs.Unit,
+			Layout:    fs.Layout,
+			Schemes:   fs.Schemes,
+			Encoding:  fs.Encoding,
+			Lenient:   fs.Lenient,
+			Separator: fs.Separator,
+			Trim:      fs.Trim,
+			OmitEmpty: fs.OmitEmpty,
+		}
+
+		// Allowed values and patterns apply to each element of slices and
+		// arrays, whose type names start with "[".
+		checkType := reflect.TypeFor[string]()
+		if strings.HasPrefix(typ, "[") {
+			checkType = reflect.TypeFor[[]string]()
+		}
+		checkErr := checkOneOf(checkType, value, tf)
+		if checkErr == nil {
+			checkErr = checkPattern(checkType, value, tf)
+		}
+		if checkErr == nil && tf.Encoding != "" && (typ == "string" || typ == "[]uint8") {
+			_, checkErr = decodeBytes(value, tf.Encoding)
+		} else if t, known := specTypes[typ]; known && checkErr == nil {
+			checkErr = set(t, reflect.New(t).Elem(), value, tf)
+		}
+		if checkErr != nil {
+			err = errors.Join(err, &FieldError{Key: key, Value: raw, Err: checkErr})
+		}
+	}
+
+	if err != nil {
+		return Errors(flattenErrors(err))
+	}
+
+	return nil
+}
@@ -0,0 +1,401 @@
+package envParser
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSpec(t *testing.T) {
+	type DB struct {
+		Host string `env:"DB_HOST,required,doc=Database host,example=db.local"`
+	}
+	type Config struct {
+		Level string `env:"LOG_LEVEL,default=info,oneof=debug|info|warn"`
+		Port  int    `env:"PORT,default=8080,doc=HTTP port\\, public"`
+		NoTag string
+		DB    DB
+	}
+
+	data, err := Spec(&Config{})
+	if err != nil {
+		t.Fatalf("Spec() error = %v", err)
+	}
+
+	var got []FieldSpec
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, data)
+	}
+
+	if len(got) != 3 {
+		t.Fatalf("Spec() returned %d fields, want 3: %s", len(got), data)
+	}
+
+	level := got[0]
+	if level.Key != "LOG_LEVEL" || level.Type != "string" || level.Default != "info" || len(level.OneOf) != 3 || level.OneOf[2] != "warn" {
+		t.Errorf("LOG_LEVEL spec = %+v", level)
+	}
+
+	port := got[1]
+	if port.Key != "PORT" || port.Type != "int" || port.Doc != "HTTP port, public" || port.Required {
+		t.Errorf("PORT spec = %+v", port)
+	}
+
+	host := got[2]
+	if host.Key != "DB_HOST" || !host.Required || host.Doc != "Database host" || host.Example != "db.local" {
+		t.Errorf("DB_HOST spec = %+v", host)
+	}
+}
+
+func TestSpecInvalidValue(t *testing.T) {
+	if _, err := Spec(nil); err != ErrInvalidValue {
+		t.Errorf("expected ErrInvalidValue for nil, got %v", err)
+	}
+	if _, err := Spec("string"); err != ErrInvalidValue {
+		t.Errorf("expected ErrInvalidValue for non-struct, got %v", err)
+	}
+}
+
+func TestSpecAliases(t *testing.T) {
+	type Config struct {
+		URL string `env:"DATABASE_URL|DB_URL"`
+	}
+
+	specs, err := fieldSpecs(&Config{})
+	if err != nil {
+		t.Fatalf("fieldSpecs() error = %v", err)
+	}
+	if len(specs) != 1 || specs[0].Key != "DATABASE_URL" || len(specs[0].Aliases) != 1 || specs[0].Aliases[0] != "DB_URL" {
+		t.Errorf("fieldSpecs() = %+v", specs)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	type Config struct {
+		Host  string        `env:"HOST,required,desc=Server host"`
+		Port  int           `env:"PORT,default=8080,doc=HTTP port"`
+		Delay time.Duration `env:"DELAY"`
+	}
+
+	var sb strings.Builder
+	if err := Usage(&sb, Config{}); err != nil {
+		t.Fatalf("Usage() error = %v", err)
+	}
+
+	want := "" +
+		"KEY    TYPE           DEFAULT  REQUIRED  DESCRIPTION\n" +
+		"HOST   string                  true      Server host\n" +
+		"PORT   int            8080               HTTP port\n" +
+		"DELAY  time.Duration                     \n"
+	if got := sb.String(); got != want {
+		t.Errorf("Usage() =\n%s\nwant\n%s", got, want)
+	}
+
+	if err := Usage(&sb, 1); err != ErrInvalidValue {
+		t.Errorf("Usage(1) error = %v, want ErrInvalidValue", err)
+	}
+}
+
+func TestGenerateTemplate(t *testing.T) {
+	type Config struct {
+		Host  string `env:"HOST,required,desc=Server host,example=localhost"`
+		Port  int    `env:"PORT,default=8080"`
+		Level string `env:"LOG_LEVEL,default=info,oneof=debug|info"`
+		Old   string `env:"OLD,deprecated=use HOST"`
+	}
+
+	got, err := GenerateTemplate(&Config{})
+	if err != nil {
+		t.Fatalf("GenerateTemplate() error = %v", err)
+	}
+
+	want := `# Server host
+# string, required
+HOST=localhost
+
+# int
+PORT=8080
+
+# string, one of debug|info
+LOG_LEVEL=info
+
+# string, deprecated: use HOST
+OLD=
+`
+	if got != want {
+		t.Errorf("GenerateTemplate() =\n%s\nwant\n%s", got, want)
+	}
+
+	// The template must load back as a valid .env file.
+	if _, err := parseEnvFile("", got); err != nil {
+		t.Errorf("template does not parse: %v", err)
+	}
+}
+
+func TestDocsMarkdown(t *testing.T) {
+	type Config struct {
+		Host  string `env:"HOST,required,desc=Server host"`
+		Port  int    `env:"PORT,default=8080,doc=HTTP port"`
+		Level string `env:"LOG_LEVEL,default=info,oneof=debug|info"`
+		Old   string `env:"OLD,deprecated=use HOST"`
+	}
+
+	got, err := DocsMarkdown(Config{})
+	if err != nil {
+		t.Fatalf("DocsMarkdown() error = %v", err)
+	}
+
+	want := "| Key | Type | Default | Required | Description |\n" +
+		"| --- | --- | --- | --- | --- |\n" +
+		"| `HOST` | `string` |  | yes | Server host |\n" +
+		"| `PORT` | `int` | `8080` | no | HTTP port |\n" +
+		"| `LOG_LEVEL` | `string` | `info` | no | One of: `debug, info`. |\n" +
+		"| `OLD` | `string` |  | no | Deprecated: use HOST |\n"
+	if got != want {
+		t.Errorf("DocsMarkdown() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestJSONSchema(t *testing.T) {
+	type Config struct {
+		Host  string        `env:"HOST,required,desc=Server host,example=localhost"`
+		Port  int           `env:"PORT,required,default=8080"`
+		Debug bool          `env:"DEBUG"`
+		Level string        `env:"LOG_LEVEL,oneof=debug|info"`
+		Name  string        `env:"NAME,notEmpty,pattern=^[a-z]+$"`
+		Delay time.Duration `env:"DELAY"`
+		Modes []string      `env:"MODES,oneof=a|b"`
+	}
+
+	data, err := JSONSchema(&Config{})
+	if err != nil {
+		t.Fatalf("JSONSchema() error = %v", err)
+	}
+
+	var got struct {
+		Schema     string                    `json:"$schema"`
+		Type       string                    `json:"type"`
+		Properties map[string]schemaProperty `json:"properties"`
+		Required   []string                  `json:"required"`
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, data)
+	}
+
+	if got.Schema != jsonSchemaDraft || got.Type != "object" || len(got.Properties) != 7 {
+		t.Fatalf("JSONSchema() = %s", data)
+	}
+	if !reflect.DeepEqual(got.Required, []string{"HOST", "NAME"}) {
+		t.Errorf("required = %v", got.Required)
+	}
+
+	host := got.Properties["HOST"]
+	if host.Type != "string" || host.Description != "Server host" || host.GoType != "string" || len(host.Examples) != 1 {
+		t.Errorf("HOST = %+v", host)
+	}
+	if port := got.Properties["PORT"]; port.Default != "8080" || port.GoType != "int" {
+		t.Errorf("PORT = %+v", port)
+	}
+	if level := got.Properties["LOG_LEVEL"]; !reflect.DeepEqual(level.Enum, []string{"debug", "info"}) || level.Pattern != "" {
+		t.Errorf("LOG_LEVEL = %+v", level)
+	}
+	if name := got.Properties["NAME"]; name.Pattern != "^[a-z]+$" || name.MinLength != 1 {
+		t.Errorf("NAME = %+v", name)
+	}
+	if delay := got.Properties["DELAY"]; delay.Pattern != "" {
+		t.Errorf("DELAY = %+v", delay)
+	}
+
+	// Generated patterns accept what Unmarshal accepts.
+	for key, values := range map[string][]string{"PORT": {"8080", "-1", "0x1F", "0b1_0"}, "DEBUG": {"true", "0", "F"}} {
+		re := regexp.MustCompile(got.Properties[key].Pattern)
+		for _, v := range values {
+			if !re.MatchString(v) {
+				t.Errorf("%s pattern %s rejects %q", key, re, v)
+			}
+		}
+	}
+	if regexp.MustCompile(got.Properties["PORT"].Pattern).MatchString("80a") {
+		t.Error("PORT pattern accepts 80a")
+	}
+
+	// Allowed values of collections are checked per element.
+	modes := got.Properties["MODES"]
+	if len(modes.Enum) != 0 {
+		t.Errorf("MODES enum = %v, want none", modes.Enum)
+	}
+	re := regexp.MustCompile(modes.Pattern)
+	for value, want := range map[string]bool{"a": true, "a;b": true, "b;a;a": true, "a;c": false, "ab": false, "": false} {
+		if re.MatchString(value) != want {
+			t.Errorf("MODES pattern %s matches %q = %v, want %v", re, value, !want, want)
+		}
+	}
+}
+
+func TestCheckSpec(t *testing.T) {
+	type Config struct {
+		Host    string        `env:"HOST|HOSTNAME,required"`
+		Port    int           `env:"PORT,default=8080"`
+		Level   string        `env:"LOG_LEVEL,oneof=debug|info"`
+		Tenant  string        `env:"TENANT,pattern=^[a-z]+$"`
+		Timeout time.Duration `env:"TIMEOUT"`
+	}
+
+	spec, err := Spec(&Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := CheckSpec(spec, map[string]string{"HOSTNAME": "db", "PORT": "0x50", "TIMEOUT": "5s"}); err != nil {
+		t.Errorf("CheckSpec() valid error = %v", err)
+	}
+
+	err = CheckSpec(spec, map[string]string{"PORT": "http", "LOG_LEVEL": "trace", "TENANT": "Acme", "TIMEOUT": "5"})
+	keys := make([]string, 0)
+	for _, fe := range FieldErrors(err) {
+		keys = append(keys, fe.Key)
+	}
+	if want := []string{"HOST", "PORT", "LOG_LEVEL", "TENANT", "TIMEOUT"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("CheckSpec() error keys = %v, want %v\n%v", keys, want, err)
+	}
+
+	if err := CheckSpec([]byte("{"), nil); err == nil {
+		t.Error("expected error for invalid spec")
+	}
+}
+
+func TestCheckSpecParseOptions(t *testing.T) {
+	defer func() { LenientBools = false }()
+	LenientBools = true
+
+	type Config struct {
+		Size    int           `env:"SIZE,unit=bytes"`
+		Timeout time.Duration `env:"TIMEOUT,unit=s"`
+		Day     time.Time     `env:"DAY,layout=2006-01-02"`
+		URL     url.URL       `env:"URL,schemes=postgres"`
+		Debug   bool          `env:"DEBUG"`
+		Token   string        `env:"TOKEN,base64"`
+		Name    string        `env:"NAME,required"`
+		Key     string        `env:"KEY,notEmpty"`
+		Port    int           `env:"PORT,required,default=8080"`
+	}
+
+	spec, err := Spec(&Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		envs     map[string]string
+		wantKeys []string
+	}{
+		{"valid", map[string]string{"SIZE": "10MB", "TIMEOUT": "30", "DAY": "2024-01-02", "URL": "postgres://db", "DEBUG": "yes", "TOKEN": "aGk=", "NAME": "", "KEY": "k", "PORT": ""}, nil},
+		{"invalid", map[string]string{"SIZE": "big", "TIMEOUT": "soon", "DAY": "02/01/2024", "URL": "http://db", "DEBUG": "maybe", "TOKEN": "!", "KEY": ""}, []string{"SIZE", "TIMEOUT", "DAY", "URL", "DEBUG", "TOKEN", "NAME", "KEY"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var keys []string
+			for _, fe := range FieldErrors(CheckSpec(spec, tt.envs)) {
+				keys = append(keys, fe.Key)
+			}
+			if !reflect.DeepEqual(keys, tt.wantKeys) {
+				t.Errorf("CheckSpec() error keys = %v, want %v", keys, tt.wantKeys)
+			}
+
+			var cfg Config
+			if err := Unmarshal(tt.envs, &cfg); (err == nil) != (tt.wantKeys == nil) {
+				t.Errorf("Unmarshal() error = %v, disagrees with CheckSpec", err)
+			}
+		})
+	}
+}
+
+func TestCheckSpecReadsLikeUnmarshal(t *testing.T) {
+	dir := t.TempDir()
+	portFile := filepath.Join(dir, "port")
+	pwFile := filepath.Join(dir, "pw")
+	if err := os.WriteFile(portFile, []byte("8080\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(pwFile, []byte("hunter2"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	type Config struct {
+		Debug bool     `env:"DEBUG,presence"`
+		Port  int      `env:"PORT,file"`
+		PW    string   `env:"PW,file,required"`
+		Modes []string `env:"MODES,oneof=a|b"`
+		Tags  []string `env:"TAGS,separator=|,trim,pattern=^[a-z]+$"`
+	}
+
+	spec, err := Spec(&Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		envs     map[string]string
+		wantKeys []string
+	}{
+		{"valid", map[string]string{"DEBUG": "", "PORT": portFile, "PW_FILE": pwFile, "MODES": "a;b", "TAGS": "x | y"}, nil},
+		{"invalid", map[string]string{"DEBUG": "maybe", "PORT": pwFile, "MODES": "a;c", "TAGS": "x|Y"}, []string{"DEBUG", "PORT", "PW", "MODES", "TAGS"}},
+		{"missing file", map[string]string{"PW": filepath.Join(dir, "missing")}, []string{"PW"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var keys []string
+			for _, fe := range FieldErrors(CheckSpec(spec, tt.envs)) {
+				keys = append(keys, fe.Key)
+				if fe.Value == "hunter2" {
+					t.Errorf("CheckSpec() error %v shows the file contents as value", fe)
+				}
+			}
+			if !reflect.DeepEqual(keys, tt.wantKeys) {
+				t.Errorf("CheckSpec() error keys = %v, want %v", keys, tt.wantKeys)
+			}
+
+			var cfg Config
+			if err := Unmarshal(tt.envs, &cfg); (err == nil) != (tt.wantKeys == nil) {
+				t.Errorf("Unmarshal() error = %v, disagrees with CheckSpec", err)
+			}
+		})
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	type DB struct {
+		Hosts []string `env:"HOSTS,separator=\\,"`
+		Pass  string   `env:"PASS,secret,required,vault=secret/db#pass"`
+	}
+	type Config struct {
+		Port int            `env:"PORT|HTTP_PORT,default=8080,desc=HTTP port"`
+		Tags map[string]int `env:"TAGS"`
+		DB   DB             `envPrefix:"DB_"`
+	}
+
+	got, err := Describe(&Config{})
+	if err != nil {
+		t.Fatalf("Describe() error = %v", err)
+	}
+
+	want := []FieldInfo{
+		{Field: "Port", Key: "PORT", Aliases: []string{"HTTP_PORT"}, Type: reflect.TypeFor[int](), Default: "8080", Description: "HTTP port"},
+		{Field: "Tags", Key: "TAGS", Type: reflect.TypeFor[map[string]int](), Separator: ";"},
+		{Field: "DB.Hosts", Key: "DB_HOSTS", Type: reflect.TypeFor[[]string](), Separator: ","},
+		{Field: "DB.Pass", Key: "DB_PASS", Type: reflect.TypeFor[string](), Required: true, Secret: true, Extra: map[string]string{"vault": "secret/db#pass"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Describe() =\n%+v\nwant\n%+v", got, want)
+	}
+}
@@ -0,0 +1,143 @@
+package envParser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// UnmarshalFromTOML reads the TOML file at path, flattens it to environment
+// keys as described in ReadTOML and unmarshals it into v, merged with the
+// current system environment variables. As with UnmarshalFromFile, file
+// values take precedence by default; set Duplicates to FirstWins to reverse
+// this.
+// v must be a non-nil pointer to a struct.
+func UnmarshalFromTOML(path string, v interface{}) error {
+	tomlEnvs, err := ReadTOML(path)
+	if err != nil {
+		return err
+	}
+
+	envs, err := EnvironToMap(os.Environ())
+	if err != nil {
+		return err
+	}
+
+	for key, value := range tomlEnvs {
+		if _, exists := envs[key]; exists && Duplicates == FirstWins {
+			continue
+		}
+		envs[key] = value
+	}
+
+	return Unmarshal(envs, v)
+}
+
+// ReadTOML reads the TOML file at path and flattens it to environment keys
+// like ReadYAML: tables are joined with "_" and upper-cased, so [db] host
+// becomes DB_HOST, arrays of scalars are joined with Separator and arrays of
+// tables are indexed like struct slices ([[servers]] host becomes
+// SERVERS_0_HOST). Offset datetimes are formatted as RFC 3339; local
+// dates and times keep their TOML form.
+func ReadTOML(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	envs, err := parseTOML(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return envs, nil
+}
+
+// TOMLSource returns a Source reading the TOML file at path like ReadTOML.
+func TOMLSource(path string) Source {
+	return SourceFunc(func(context.Context) (map[string]string, error) {
+		return ReadTOML(path)
+	})
+}
+
+func parseTOML(data []byte) (map[string]string, error) {
+	var doc map[string]interface{}
+	if err := toml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	envs := make(map[string]string)
+	for key, value := range doc {
+		flattenValue(envs, EnvKey(key), value)
+	}
+
+	return envs, nil
+}
+
+// flattenValue stores the scalars of a decoded TOML or JSON value in envs
+// under key and the keys derived from it.
+func flattenValue(envs map[string]string, key string, value interface{}) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for k, v := range value {
+			flattenValue(envs, key+"_"+EnvKey(k), v)
+		}
+	case []map[string]interface{}:
+		for i, table := range value {
+			flattenValue(envs, key+"_"+strconv.Itoa(i), table)
+		}
+	case []interface{}:
+		scalars := make([]string, 0, len(value))
+		for _, elem := range value {
+			s, ok := scalarString(elem)
+			if !ok {
+				// Mixed arrays are indexed like arrays of tables.
+				for i, elem := range value {
+					flattenValue(envs, key+"_"+strconv.Itoa(i), elem)
+				}
+				return
+			}
+			scalars = append(scalars, s)
+		}
+		envs[key] = strings.Join(scalars, Separator)
+	default:
+		if s, ok := scalarString(value); ok {
+			envs[key] = s
+		}
+	}
+}
+
+// scalarString formats a decoded TOML or JSON scalar.
+func scalarString(value interface{}) (string, bool) {
+	switch value := value.(type) {
+	case string:
+		return value, true
+	case json.Number:
+		return value.String(), true
+	case int64:
+		return strconv.FormatInt(value, 10), true
+	case float64:
+		return strconv.FormatFloat(value, 'g', -1, 64), true
+	case bool:
+		return strconv.FormatBool(value), true
+	case time.Time:
+		// Local dates and times are decoded into marker locations.
+		switch value.Location().String() {
+		case "date-local":
+			return value.Format(time.DateOnly), true
+		case "time-local":
+			return value.Format("15:04:05.999999999"), true
+		case "datetime-local":
+			return value.Format("2006-01-02T15:04:05.999999999"), true
+		}
+		return value.Format(time.RFC3339Nano), true
+	default:
+		return "", false
+	}
+}
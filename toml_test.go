@@ -0,0 +1,74 @@
+package envParser
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseTOML(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    map[string]string
+		wantErr bool
+	}{
+		{"empty", "", map[string]string{}, false},
+		{"tables", "log-level = \"debug\"\n[db]\nhost = \"localhost\"\nport = 5432\n[db.pool]\nsize = 1.5\n", map[string]string{"LOG_LEVEL": "debug", "DB_HOST": "localhost", "DB_PORT": "5432", "DB_POOL_SIZE": "1.5"}, false},
+		{"scalar array", "tags = [\"a\", \"b\"]\nports = [80, 443]\n", map[string]string{"TAGS": "a;b", "PORTS": "80;443"}, false},
+		{"array of tables", "[[servers]]\nhost = \"a\"\n[[servers]]\nhost = \"b\"\nport = 81\n", map[string]string{"SERVERS_0_HOST": "a", "SERVERS_1_HOST": "b", "SERVERS_1_PORT": "81"}, false},
+		{"datetimes", "at = 2024-01-02T03:04:05Z\nday = 2024-01-02\nclock = 03:04:05\nlocal = 2024-01-02T03:04:05\nok = true\n", map[string]string{"AT": "2024-01-02T03:04:05Z", "DAY": "2024-01-02", "CLOCK": "03:04:05", "LOCAL": "2024-01-02T03:04:05", "OK": "true"}, false},
+		{"invalid", "a = \n", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTOML([]byte(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTOML() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseTOML() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshalFromTOML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	content := "hosts = [\"a\", \"b\"]\n[db]\nhost = \"db.local\"\ntimeout = \"5s\"\n[[servers]]\nhost = \"s1\"\nport = 81\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	type Server struct {
+		Host string `env:"HOST"`
+		Port int    `env:"PORT"`
+	}
+
+	type Config struct {
+		DB struct {
+			Host    string        `env:"HOST"`
+			Timeout time.Duration `env:"TIMEOUT"`
+		} `envPrefix:"DB_"`
+		Hosts   []string `env:"HOSTS"`
+		Servers []Server `envPrefix:"SERVERS_"`
+	}
+
+	var cfg Config
+	if err := UnmarshalFromTOML(path, &cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.DB.Host != "db.local" || cfg.DB.Timeout != 5*time.Second {
+		t.Errorf("DB = %+v", cfg.DB)
+	}
+	if !reflect.DeepEqual(cfg.Hosts, []string{"a", "b"}) {
+		t.Errorf("Hosts = %v", cfg.Hosts)
+	}
+	if !reflect.DeepEqual(cfg.Servers, []Server{{Host: "s1", Port: 81}}) {
+		t.Errorf("Servers = %+v", cfg.Servers)
+	}
+}
@@ -0,0 +1,123 @@
+package envParser
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Usage writes a table of every environment variable read into v, with its
+// type, default, whether it is required and its description (from the doc or
+// desc tag option), e.g. for --help output.
+// v must be a struct or a pointer to a struct.
+func Usage(w io.Writer, v interface{}) error {
+	specs, err := fieldSpecs(v)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tTYPE\tDEFAULT\tREQUIRED\tDESCRIPTION")
+	for _, spec := range specs {
+		required := ""
+		if spec.Required {
+			required = "true"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", spec.Key, spec.Type, spec.Default, required, spec.Doc)
+	}
+
+	return tw.Flush()
+}
+
+// GenerateTemplate returns a .env.example file for v with one KEY=value line
+// per variable, preceded by comments holding its description, type, whether
+// it is required and its allowed values. Values are the defaults, or the
+// examples for fields without one.
+// v must be a struct or a pointer to a struct.
+func GenerateTemplate(v interface{}) (string, error) {
+	specs, err := fieldSpecs(v)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	for i, spec := range specs {
+		if i > 0 {
+			sb.WriteByte('\n')
+		}
+
+		if spec.Doc != "" {
+			fmt.Fprintf(&sb, "# %s\n", spec.Doc)
+		}
+
+		details := []string{spec.Type}
+		if spec.Required {
+			details = append(details, "required")
+		}
+		if len(spec.OneOf) > 0 {
+			details = append(details, "one of "+strings.Join(spec.OneOf, "|"))
+		}
+		if spec.Deprecated != "" {
+			details = append(details, "deprecated: "+spec.Deprecated)
+		}
+		fmt.Fprintf(&sb, "# %s\n", strings.Join(details, ", "))
+
+		value := spec.Default
+		if value == "" {
+			value = spec.Example
+		}
+		fmt.Fprintf(&sb, "%s=%s\n", spec.Key, value)
+	}
+
+	return sb.String(), nil
+}
+
+// DocsMarkdown returns a Markdown table of every environment variable read
+// into v, with its type, default, whether it is required and its
+// description, for inclusion in project documentation.
+// v must be a struct or a pointer to a struct.
+func DocsMarkdown(v interface{}) (string, error) {
+	specs, err := fieldSpecs(v)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	sb.WriteString("| Key | Type | Default | Required | Description |\n")
+	sb.WriteString("| --- | --- | --- | --- | --- |\n")
+	for _, spec := range specs {
+		required := "no"
+		if spec.Required {
+			required = "yes"
+		}
+
+		description := spec.Doc
+		if len(spec.OneOf) > 0 {
+			description = strings.TrimSpace(description + " One of: " + markdownCode(strings.Join(spec.OneOf, ", ")) + ".")
+		}
+		if spec.Deprecated != "" {
+			description = strings.TrimSpace(description + " Deprecated: " + spec.Deprecated)
+		}
+
+		fmt.Fprintf(&sb, "| %s | %s | %s | %s | %s |\n",
+			markdownCode(spec.Key), markdownCode(spec.Type), markdownCode(spec.Default), required, markdownEscape(description))
+	}
+
+	return sb.String(), nil
+}
+
+// markdownCode formats s as inline code for a table cell, or returns an
+// empty string if s is empty.
+func markdownCode(s string) string {
+	if s == "" {
+		return ""
+	}
+
+	return "`" + markdownEscape(s) + "`"
+}
+
+// markdownEscape escapes the pipes in s so it fits in a table cell.
+func markdownEscape(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
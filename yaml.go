@@ -0,0 +1,152 @@
+package envParser
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// UnmarshalFromYAML reads the YAML file at path, flattens it to environment
+// keys as described in ReadYAML and unmarshals it into v, merged with the
+// current system environment variables. As with UnmarshalFromFile, file
+// values take precedence by default; set Duplicates to FirstWins to reverse
+// this.
+// v must be a non-nil pointer to a struct.
+func UnmarshalFromYAML(path string, v interface{}) error {
+	yamlEnvs, err := ReadYAML(path)
+	if err != nil {
+		return err
+	}
+
+	envs, err := EnvironToMap(os.Environ())
+	if err != nil {
+		return err
+	}
+
+	for key, value := range yamlEnvs {
+		if _, exists := envs[key]; exists && Duplicates == FirstWins {
+			continue
+		}
+		envs[key] = value
+	}
+
+	return Unmarshal(envs, v)
+}
+
+// ReadYAML reads the YAML file at path and flattens it to environment keys:
+// nested mapping keys are joined with "_" and upper-cased, with "-" and "."
+// replaced by "_", so db.host becomes DB_HOST. Lists of scalars are joined
+// with Separator; other lists are indexed like struct slices, so
+// servers[0].host becomes SERVERS_0_HOST. Scalars keep their literal text
+// and null values are skipped. Map fields must be written as strings in the
+// field's key:value format.
+func ReadYAML(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	envs, err := parseYAML(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return envs, nil
+}
+
+// YAMLSource returns a Source reading the YAML file at path like ReadYAML.
+func YAMLSource(path string) Source {
+	return SourceFunc(func(context.Context) (map[string]string, error) {
+		return ReadYAML(path)
+	})
+}
+
+func parseYAML(data []byte) (map[string]string, error) {
+	envs := make(map[string]string)
+	if len(bytes.TrimSpace(data)) == 0 {
+		return envs, nil
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return envs, nil
+	}
+
+	root := resolveYAMLAlias(doc.Content[0])
+	if root.Kind != yaml.MappingNode {
+		return nil, errors.New("top-level YAML value must be a mapping")
+	}
+
+	flattenYAML(envs, "", root)
+
+	return envs, nil
+}
+
+// flattenYAML stores the scalars of n in envs under keys starting with
+// prefix.
+func flattenYAML(envs map[string]string, prefix string, n *yaml.Node) {
+	n = resolveYAMLAlias(n)
+
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			if key.Value == "<<" && key.Tag == "!!merge" {
+				// Merge keys splice the referenced mappings in place.
+				if value = resolveYAMLAlias(value); value.Kind == yaml.SequenceNode {
+					for _, m := range value.Content {
+						flattenYAML(envs, prefix, m)
+					}
+				} else {
+					flattenYAML(envs, prefix, value)
+				}
+				continue
+			}
+			flattenYAML(envs, prefix+EnvKey(key.Value)+"_", value)
+		}
+	case yaml.SequenceNode:
+		key := strings.TrimSuffix(prefix, "_")
+		if scalars, ok := yamlScalars(n); ok {
+			envs[key] = strings.Join(scalars, Separator)
+			return
+		}
+		for i, elem := range n.Content {
+			flattenYAML(envs, prefix+strconv.Itoa(i)+"_", elem)
+		}
+	case yaml.ScalarNode:
+		if n.Tag == "!!null" {
+			return
+		}
+		envs[strings.TrimSuffix(prefix, "_")] = n.Value
+	}
+}
+
+// yamlScalars returns the values of a sequence made only of scalars.
+func yamlScalars(n *yaml.Node) ([]string, bool) {
+	values := make([]string, 0, len(n.Content))
+	for _, elem := range n.Content {
+		elem = resolveYAMLAlias(elem)
+		if elem.Kind != yaml.ScalarNode {
+			return nil, false
+		}
+		values = append(values, elem.Value)
+	}
+
+	return values, true
+}
+
+func resolveYAMLAlias(n *yaml.Node) *yaml.Node {
+	for n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	return n
+}
@@ -0,0 +1,76 @@
+package envParser
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseYAML(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    map[string]string
+		wantErr bool
+	}{
+		{"empty", "", map[string]string{}, false},
+		{"nested", "db:\n  host: localhost\n  port: 5432\nlog-level: debug\n", map[string]string{"DB_HOST": "localhost", "DB_PORT": "5432", "LOG_LEVEL": "debug"}, false},
+		{"literal scalars", "mode: 0755\ntimeout: 5s\nname: null\n", map[string]string{"MODE": "0755", "TIMEOUT": "5s"}, false},
+		{"scalar list", "tags: [a, b, c]\n", map[string]string{"TAGS": "a;b;c"}, false},
+		{"struct list", "servers:\n  - host: a\n  - host: b\n    port: 81\n", map[string]string{"SERVERS_0_HOST": "a", "SERVERS_1_HOST": "b", "SERVERS_1_PORT": "81"}, false},
+		{"anchors", "base: &base\n  port: 80\nweb:\n  <<: *base\n  host: w\n", map[string]string{"BASE_PORT": "80", "WEB_PORT": "80", "WEB_HOST": "w"}, false},
+		{"not a mapping", "- a\n- b\n", nil, true},
+		{"invalid", "a: [b\n", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseYAML([]byte(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseYAML() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseYAML() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshalFromYAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := "db:\n  host: db.local\n  timeout: 5s\nhosts:\n  - a\n  - b\nservers:\n  - host: s1\n    port: 81\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	type Server struct {
+		Host string `env:"HOST"`
+		Port int    `env:"PORT"`
+	}
+
+	type Config struct {
+		DB struct {
+			Host    string        `env:"HOST"`
+			Timeout time.Duration `env:"TIMEOUT"`
+		} `envPrefix:"DB_"`
+		Hosts   []string `env:"HOSTS"`
+		Servers []Server `envPrefix:"SERVERS_"`
+	}
+
+	var cfg Config
+	if err := UnmarshalFromYAML(path, &cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.DB.Host != "db.local" || cfg.DB.Timeout != 5*time.Second {
+		t.Errorf("DB = %+v", cfg.DB)
+	}
+	if !reflect.DeepEqual(cfg.Hosts, []string{"a", "b"}) {
+		t.Errorf("Hosts = %v", cfg.Hosts)
+	}
+	if !reflect.DeepEqual(cfg.Servers, []Server{{Host: "s1", Port: 81}}) {
+		t.Errorf("Servers = %+v", cfg.Servers)
+	}
+}